@@ -8,13 +8,19 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	metricInstrument "go.opentelemetry.io/otel/metric/instrument"
 	"net/http"
+	"os"
 	"otlp-stack/config"
 	"otlp-stack/internal/telemetry"
 	"otlp-stack/pkg/log"
+	"otlp-stack/pkg/opentelemetry/semconv"
 )
 
 func main() {
-	l := log.Init(log.WithDevelopment(true), log.WithLevel(0))
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	l := log.Init(log.WithDevelopment(true), log.WithLevel(0), log.WithSpanEvents(true))
 	ctx := context.Background()
 	cfg, err := config.Load(ctx)
 	if err != nil {
@@ -30,6 +36,10 @@ func main() {
 		l.V(5).Info("%s: %v", "Failed to initialize opentelemetry provider", err)
 	}
 
+	if instrument != nil && instrument.Logging() != nil {
+		l = log.Init(log.WithDevelopment(true), log.WithLevel(0), log.WithSpanEvents(true), log.WithOTLP(instrument.Logging()))
+	}
+
 	StartGin(instrument)
 
 }
@@ -40,6 +50,14 @@ func StartGin(inst telemetry.Instrument) {
 	router := gin.New()
 	router.Use(otelgin.Middleware("test-otlp"))
 
+	if inst != nil && inst.Metric() != nil {
+		if m, ok := inst.Metric().(interface{ PrometheusHandler() http.Handler }); ok {
+			if h := m.PrometheusHandler(); h != nil {
+				router.GET("/metrics", gin.WrapH(h))
+			}
+		}
+	}
+
 	router.GET("/", func(c *gin.Context) {
 		obsCounter, err := inst.Metric().Int64Counter(
 			"request.count",
@@ -53,13 +71,14 @@ func StartGin(inst telemetry.Instrument) {
 		ctx, span := inst.StartRootSpan(c.Request.Context(), "my-gin-server.handler")
 		defer span.End()
 		ctx.Done()
+		log.FromContext(ctx).Info("handling request", "path", c.Request.URL.Path)
 		// Set attributes on the span
-		span.SetAttributes(attribute.String("http.method", c.Request.Method), attribute.String("http.path", c.Request.URL.Path))
+		span.SetAttributes(semconv.HTTPMethod(c.Request.Method), semconv.HTTPRoute(c.FullPath()), semconv.HTTPTarget(c.Request.URL.Path))
 
 		// Do some work
 		attrs := []attribute.KeyValue{
-			attribute.String("path", "/"),
-			attribute.String("method", c.Request.Method),
+			semconv.HTTPRoute(c.FullPath()),
+			semconv.HTTPMethod(c.Request.Method),
 		}
 		obsCounter.Add(ctx, 1, attrs...)
 		c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	metricInstrument "go.opentelemetry.io/otel/metric/instrument"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"otlp-stack/config"
+	"otlp-stack/internal/telemetry"
+	otelpp "otlp-stack/pkg/opentelemetry"
+)
+
+// Names of the telemetry sent by the ping command, to look for in the
+// backends, e.g. otelpp_ping_total in Prometheus.
+const (
+	pingSpanName      = "otlp-stack.ping"
+	pingChildSpanName = "otlp-stack.ping.child"
+	pingCounterName   = "otelpp.ping"
+	pingHistogramName = "otelpp.ping.duration"
+	pingIDKey         = attribute.Key("ping.id")
+)
+
+var errPingFailed = errors.New("at least one signal failed")
+
+type pingResult struct {
+	signal  string
+	latency time.Duration
+	err     error
+	skipped bool
+	detail  string
+}
+
+/*
+runPing sends a known trace and metric set with the providers configured by
+the application configuration, the .env file and the environment, waits for
+the export and reports the result of each signal, to validate the pipeline
+of a new environment:
+
+	otlp-stack ping -timeout 5s
+
+The trace has a root span named otlp-stack.ping with a child span, both with
+the ping.id attribute, and the metrics are the otelpp.ping counter and the
+otelpp.ping.duration histogram. The spans are always sampled.
+*/
+func runPing(args []string) error {
+	fs := flag.NewFlagSet("ping", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of the export of each signal")
+	retry := fs.Bool("retry", false, "retry the failed exports like the service, the error is reported after the retries")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	cfg, err := config.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the errors are reported by the command, not logged
+	opts, err := telemetry.ProviderOptions(logr.Discard(), cfg)
+	if err != nil {
+		return err
+	}
+
+	opts = append(opts, otelpp.WithTrace(otelpp.WithSampler(sdktrace.AlwaysSample())))
+	if !*retry {
+		opts = append(opts, otelpp.WithRetry(otelpp.WithRetryEnable(false)))
+	}
+
+	tracing, meter, err := otelpp.NewProvider(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	id := newPingID()
+	results := []pingResult{
+		pingTraces(tracing, id, *timeout),
+		pingMetrics(meter, *timeout),
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	_ = otelpp.Shutdown(shutdownCtx, tracing, meter)
+
+	fmt.Printf("ping.id=%s\n\n", id)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SIGNAL\tSTATUS\tLATENCY\tDETAIL")
+
+	failed := false
+	for _, r := range results {
+		status, detail := "ok", r.detail
+		switch {
+		case r.skipped:
+			status = "skipped"
+		case r.err != nil:
+			status, detail, failed = "failed", r.err.Error(), true
+		}
+
+		latency := "-"
+		if !r.skipped {
+			latency = r.latency.Round(time.Millisecond).String()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.signal, status, latency, detail)
+	}
+
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if failed {
+		return errPingFailed
+	}
+
+	return nil
+}
+
+// pingTraces ends the ping spans and exports them, returning the result of
+// the export.
+func pingTraces(tracing otelpp.Telemetry, id string, timeout time.Duration) pingResult {
+	r := pingResult{signal: "traces"}
+	if tracing == nil {
+		r.skipped, r.detail = true, "no trace endpoint configured"
+		return r
+	}
+
+	ctx, root := tracing.Start(context.Background(), pingSpanName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(pingIDKey.String(id)))
+	_, child := tracing.Start(ctx, pingChildSpanName, trace.WithAttributes(pingIDKey.String(id)))
+	child.End()
+	root.End()
+
+	sc := root.SpanContext()
+	if !sc.IsSampled() {
+		r.err = errors.New("the ping span was not sampled, check the sampling rules")
+		return r
+	}
+
+	r.latency, r.err = flush(tracing, timeout)
+	r.detail = "trace_id=" + sc.TraceID().String()
+
+	return r
+}
+
+// pingMetrics records the ping metrics and exports them, returning the
+// result of the export.
+func pingMetrics(meter otelpp.Meter, timeout time.Duration) pingResult {
+	r := pingResult{signal: "metrics"}
+	if meter == nil {
+		r.skipped, r.detail = true, "no metric endpoint configured"
+		return r
+	}
+
+	counter, err := meter.Int64Counter(pingCounterName,
+		metricInstrument.WithDescription("pings sent by the otlp-stack ping command"),
+		metricInstrument.WithUnit("{ping}"))
+	if err != nil {
+		r.err = err
+		return r
+	}
+
+	histogram, err := meter.Float64Histogram(pingHistogramName,
+		metricInstrument.WithDescription("fixed duration recorded by the otlp-stack ping command"),
+		metricInstrument.WithUnit("ms"))
+	if err != nil {
+		r.err = err
+		return r
+	}
+
+	counter.Add(context.Background(), 1)
+	histogram.Record(context.Background(), 1)
+
+	r.latency, r.err = flush(meter, timeout)
+	r.detail = "metrics=" + pingCounterName + "," + pingHistogramName
+
+	return r
+}
+
+// flusher is implemented by the Telemetry and Meter of otelpp exporting
+// their pending data on demand.
+type flusher interface {
+	ForceFlush(ctx context.Context) error
+}
+
+// flush exports the pending data of provider within timeout, returning the
+// time spent. It fails when the provider cannot be flushed.
+func flush(provider interface{}, timeout time.Duration) (time.Duration, error) {
+	f, ok := provider.(flusher)
+	if !ok {
+		return 0, errors.New("the provider does not support flushing")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := f.ForceFlush(ctx)
+
+	return time.Since(start), err
+}
+
+func newPingID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
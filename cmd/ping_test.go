@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	otelpp "otlp-stack/pkg/opentelemetry"
+	"otlp-stack/pkg/opentelemetry/fakecollector"
+)
+
+func TestPing(t *testing.T) {
+	c, tracing, meter := startPingProviders(t)
+
+	r := pingTraces(tracing, "0123456789abcdef", 5*time.Second)
+	if r.err != nil || r.skipped || !strings.HasPrefix(r.detail, "trace_id=") {
+		t.Fatalf("traces = %+v, want ok with the trace ID", r)
+	}
+
+	root, ok := c.FindSpan(pingSpanName)
+	if !ok {
+		t.Fatalf("span %s not received", pingSpanName)
+	}
+	if "trace_id="+hex.EncodeToString(root.TraceId) != r.detail {
+		t.Errorf("received trace %x, reported %s", root.TraceId, r.detail)
+	}
+	child, ok := c.FindSpan(pingChildSpanName)
+	if !ok || string(child.ParentSpanId) != string(root.SpanId) {
+		t.Errorf("child span %s not received under the root span", pingChildSpanName)
+	}
+	if id := spanAttribute(root, string(pingIDKey)); id != "0123456789abcdef" {
+		t.Errorf("ping.id = %q, want 0123456789abcdef", id)
+	}
+
+	r = pingMetrics(meter, 5*time.Second)
+	if r.err != nil || r.skipped {
+		t.Fatalf("metrics = %+v, want ok", r)
+	}
+	for _, name := range []string{pingCounterName, pingHistogramName} {
+		if _, ok := c.FindMetric(name); !ok {
+			t.Errorf("metric %s not received", name)
+		}
+	}
+}
+
+func TestPing_Failed(t *testing.T) {
+	c, tracing, meter := startPingProviders(t)
+	c.FailNext(0, fakecollector.Unavailable())
+
+	if r := pingTraces(tracing, "id", 5*time.Second); r.err == nil {
+		t.Errorf("traces = %+v, want the export error", r)
+	}
+	if r := pingMetrics(meter, 5*time.Second); r.err == nil {
+		t.Errorf("metrics = %+v, want the export error", r)
+	}
+}
+
+func TestPing_Skipped(t *testing.T) {
+	if r := pingTraces(nil, "id", time.Second); !r.skipped || r.err != nil {
+		t.Errorf("traces = %+v, want skipped", r)
+	}
+	if r := pingMetrics(nil, time.Second); !r.skipped || r.err != nil {
+		t.Errorf("metrics = %+v, want skipped", r)
+	}
+}
+
+func TestFlush_Unsupported(t *testing.T) {
+	if _, err := flush(struct{}{}, time.Second); err == nil {
+		t.Error("flush of a provider without ForceFlush: nil error")
+	}
+}
+
+// startPingProviders returns the providers of ping exporting to a fake
+// collector without retry.
+func startPingProviders(t *testing.T) (*fakecollector.Collector, otelpp.Telemetry, otelpp.Meter) {
+	t.Helper()
+
+	c, err := fakecollector.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Stop(context.Background()) })
+
+	tracing, meter, err := otelpp.NewProvider(context.Background(),
+		otelpp.WithAppEnv(otelpp.DEV),
+		otelpp.WithServiceName("ping-test"),
+		otelpp.WithTraceEndpoint(c.GRPCEndpoint()),
+		otelpp.WithMetricEndpoint(c.GRPCEndpoint()),
+		otelpp.WithInsecure(true),
+		otelpp.WithTrace(otelpp.WithSampler(sdktrace.AlwaysSample())),
+		otelpp.WithRetry(otelpp.WithRetryEnable(false)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.ClearFailures()
+		_ = otelpp.Shutdown(context.Background(), tracing, meter)
+	})
+
+	return c, tracing, meter
+}
+
+func spanAttribute(s fakecollector.Span, key string) string {
+	for _, kv := range s.Attributes {
+		if kv.Key == key {
+			return kv.Value.GetStringValue()
+		}
+	}
+	return ""
+}
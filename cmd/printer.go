@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+	"otlp-stack/pkg/opentelemetry/fakecollector"
+)
+
+/*
+printer writes the export requests as readable text. The spans are printed
+as a tree per trace, nested by parent. The tree is built per export request,
+so a span whose parent was exported in another request is printed as a root.
+*/
+type printer struct {
+	mu         sync.Mutex
+	w          io.Writer
+	attributes bool
+}
+
+func (p *printer) print(req proto.Message) {
+	var b bytes.Buffer
+
+	switch r := req.(type) {
+	case *coltracepb.ExportTraceServiceRequest:
+		for _, rs := range r.ResourceSpans {
+			p.printResourceSpans(&b, rs)
+		}
+	case *colmetricpb.ExportMetricsServiceRequest:
+		for _, rm := range r.ResourceMetrics {
+			p.printResourceMetrics(&b, rm)
+		}
+	case *collogspb.ExportLogsServiceRequest:
+		for _, rl := range r.ResourceLogs {
+			p.printResourceLogs(&b, rl)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, _ = p.w.Write(b.Bytes())
+}
+
+func (p *printer) printResource(b *bytes.Buffer, signal string, res *resourcepb.Resource) {
+	fmt.Fprintf(b, "=== %s %s %s\n", time.Now().Format(time.TimeOnly), signal, serviceLabel(res))
+	if p.attributes && len(res.GetAttributes()) > 0 {
+		fmt.Fprintf(b, "resource %s\n", attributesString(res.GetAttributes()))
+	}
+}
+
+func (p *printer) printResourceSpans(b *bytes.Buffer, rs *tracepb.ResourceSpans) {
+	p.printResource(b, "traces", rs.Resource)
+
+	var spans []*tracepb.Span
+	for _, ss := range rs.ScopeSpans {
+		spans = append(spans, ss.Spans...)
+	}
+
+	byID := make(map[string]*tracepb.Span, len(spans))
+	for _, s := range spans {
+		byID[spanKey(s.TraceId, s.SpanId)] = s
+	}
+
+	children := make(map[string][]*tracepb.Span)
+	traces := make(map[string][]*tracepb.Span)
+	var traceIDs []string
+	for _, s := range spans {
+		parent := spanKey(s.TraceId, s.ParentSpanId)
+		if _, ok := byID[parent]; ok && len(s.ParentSpanId) > 0 {
+			children[parent] = append(children[parent], s)
+			continue
+		}
+
+		traceID := hex.EncodeToString(s.TraceId)
+		if _, ok := traces[traceID]; !ok {
+			traceIDs = append(traceIDs, traceID)
+		}
+		traces[traceID] = append(traces[traceID], s)
+	}
+
+	for _, traceID := range traceIDs {
+		fmt.Fprintf(b, "trace %s\n", traceID)
+		roots := traces[traceID]
+		sortSpans(roots)
+		for i, s := range roots {
+			p.printSpan(b, s, children, "", i == len(roots)-1)
+		}
+	}
+}
+
+func (p *printer) printSpan(b *bytes.Buffer, s *tracepb.Span, children map[string][]*tracepb.Span, prefix string, last bool) {
+	branch, indent := "├─ ", "│  "
+	if last {
+		branch, indent = "└─ ", "   "
+	}
+
+	duration := time.Duration(s.EndTimeUnixNano - s.StartTimeUnixNano).Round(time.Microsecond)
+	fmt.Fprintf(b, "%s%s%s [%s] %s", prefix, branch, s.Name, spanKind(s.Kind), duration)
+	if s.Status.GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+		fmt.Fprintf(b, " ERROR %q", s.Status.GetMessage())
+	}
+	if p.attributes && len(s.Attributes) > 0 {
+		fmt.Fprintf(b, " %s", attributesString(s.Attributes))
+	}
+	b.WriteString("\n")
+
+	for _, e := range s.Events {
+		fmt.Fprintf(b, "%s%s• %s", prefix, indent, e.Name)
+		if p.attributes && len(e.Attributes) > 0 {
+			fmt.Fprintf(b, " %s", attributesString(e.Attributes))
+		}
+		b.WriteString("\n")
+	}
+
+	spans := children[spanKey(s.TraceId, s.SpanId)]
+	sortSpans(spans)
+	for i, child := range spans {
+		p.printSpan(b, child, children, prefix+indent, i == len(spans)-1)
+	}
+}
+
+func (p *printer) printResourceMetrics(b *bytes.Buffer, rm *metricpb.ResourceMetrics) {
+	p.printResource(b, "metrics", rm.Resource)
+
+	for _, sm := range rm.ScopeMetrics {
+		fmt.Fprintf(b, "scope %s\n", scopeString(sm.Scope))
+		for _, m := range sm.Metrics {
+			p.printMetric(b, m)
+		}
+	}
+}
+
+func (p *printer) printMetric(b *bytes.Buffer, m *metricpb.Metric) {
+	fmt.Fprintf(b, "  %s (%s)", m.Name, metricType(m))
+	if m.Unit != "" {
+		fmt.Fprintf(b, " unit=%s", m.Unit)
+	}
+	if m.Description != "" {
+		fmt.Fprintf(b, " %q", m.Description)
+	}
+	b.WriteString("\n")
+
+	point := func(attrs []*commonpb.KeyValue, value string) {
+		b.WriteString("    ")
+		if p.attributes {
+			fmt.Fprintf(b, "%s ", attributesString(attrs))
+		}
+		fmt.Fprintln(b, value)
+	}
+
+	switch data := m.Data.(type) {
+	case *metricpb.Metric_Gauge:
+		for _, dp := range data.Gauge.DataPoints {
+			point(dp.Attributes, numberValue(dp))
+		}
+	case *metricpb.Metric_Sum:
+		for _, dp := range data.Sum.DataPoints {
+			point(dp.Attributes, numberValue(dp))
+		}
+	case *metricpb.Metric_Histogram:
+		for _, dp := range data.Histogram.DataPoints {
+			point(dp.Attributes, histogramValue(dp))
+		}
+	case *metricpb.Metric_ExponentialHistogram:
+		for _, dp := range data.ExponentialHistogram.DataPoints {
+			point(dp.Attributes, fmt.Sprintf("count=%d sum=%g scale=%d zero=%d", dp.Count, dp.GetSum(), dp.Scale, dp.ZeroCount))
+		}
+	case *metricpb.Metric_Summary:
+		for _, dp := range data.Summary.DataPoints {
+			quantiles := make([]string, 0, len(dp.QuantileValues))
+			for _, q := range dp.QuantileValues {
+				quantiles = append(quantiles, fmt.Sprintf("p%g=%g", q.Quantile*100, q.Value))
+			}
+			point(dp.Attributes, fmt.Sprintf("count=%d sum=%g %s", dp.Count, dp.Sum, strings.Join(quantiles, " ")))
+		}
+	}
+}
+
+func (p *printer) printResourceLogs(b *bytes.Buffer, rl *logspb.ResourceLogs) {
+	p.printResource(b, "logs", rl.Resource)
+
+	for _, sl := range rl.ScopeLogs {
+		for _, r := range sl.LogRecords {
+			ts := r.TimeUnixNano
+			if ts == 0 {
+				ts = r.ObservedTimeUnixNano
+			}
+			fmt.Fprintf(b, "  %s %-5s %s", time.Unix(0, int64(ts)).Format(time.TimeOnly+".000"), severity(r), anyValueString(r.Body))
+			if p.attributes && len(r.Attributes) > 0 {
+				fmt.Fprintf(b, " %s", attributesString(r.Attributes))
+			}
+			if len(r.TraceId) > 0 {
+				fmt.Fprintf(b, " trace=%s span=%s", hex.EncodeToString(r.TraceId), hex.EncodeToString(r.SpanId))
+			}
+			b.WriteString("\n")
+		}
+	}
+}
+
+func spanKey(traceID, spanID []byte) string {
+	return string(traceID) + string(spanID)
+}
+
+func sortSpans(spans []*tracepb.Span) {
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].StartTimeUnixNano < spans[j].StartTimeUnixNano
+	})
+}
+
+func spanKind(k tracepb.Span_SpanKind) string {
+	return strings.TrimPrefix(k.String(), "SPAN_KIND_")
+}
+
+func serviceLabel(res *resourcepb.Resource) string {
+	name := fakecollector.ServiceName(res)
+	if name == "" {
+		return "service=<unknown>"
+	}
+	return "service=" + name
+}
+
+func scopeString(s *commonpb.InstrumentationScope) string {
+	if s.GetVersion() == "" {
+		return s.GetName()
+	}
+	return s.GetName() + "@" + s.GetVersion()
+}
+
+func metricType(m *metricpb.Metric) string {
+	switch data := m.Data.(type) {
+	case *metricpb.Metric_Gauge:
+		return "gauge"
+	case *metricpb.Metric_Sum:
+		kind := "sum"
+		if data.Sum.IsMonotonic {
+			kind = "counter"
+		}
+		return kind + ", " + temporality(data.Sum.AggregationTemporality)
+	case *metricpb.Metric_Histogram:
+		return "histogram, " + temporality(data.Histogram.AggregationTemporality)
+	case *metricpb.Metric_ExponentialHistogram:
+		return "exponential histogram, " + temporality(data.ExponentialHistogram.AggregationTemporality)
+	case *metricpb.Metric_Summary:
+		return "summary"
+	default:
+		return "unknown"
+	}
+}
+
+func temporality(t metricpb.AggregationTemporality) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "AGGREGATION_TEMPORALITY_"))
+}
+
+func numberValue(dp *metricpb.NumberDataPoint) string {
+	switch v := dp.Value.(type) {
+	case *metricpb.NumberDataPoint_AsInt:
+		return strconv.FormatInt(v.AsInt, 10)
+	case *metricpb.NumberDataPoint_AsDouble:
+		return strconv.FormatFloat(v.AsDouble, 'g', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func histogramValue(dp *metricpb.HistogramDataPoint) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "count=%d sum=%g", dp.Count, dp.GetSum())
+	if dp.Min != nil {
+		fmt.Fprintf(&b, " min=%g", dp.GetMin())
+	}
+	if dp.Max != nil {
+		fmt.Fprintf(&b, " max=%g", dp.GetMax())
+	}
+
+	for i, count := range dp.BucketCounts {
+		if count == 0 {
+			continue
+		}
+		bound := "+Inf"
+		if i < len(dp.ExplicitBounds) {
+			bound = strconv.FormatFloat(dp.ExplicitBounds[i], 'g', -1, 64)
+		}
+		fmt.Fprintf(&b, " le%s=%d", bound, count)
+	}
+
+	return b.String()
+}
+
+func severity(r *logspb.LogRecord) string {
+	if r.SeverityText != "" {
+		return r.SeverityText
+	}
+	return strings.TrimPrefix(r.SeverityNumber.String(), "SEVERITY_NUMBER_")
+}
+
+func attributesString(attrs []*commonpb.KeyValue) string {
+	parts := make([]string, 0, len(attrs))
+	for _, kv := range attrs {
+		parts = append(parts, kv.Key+"="+anyValueString(kv.Value))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func anyValueString(v *commonpb.AnyValue) string {
+	switch v := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	case *commonpb.AnyValue_BytesValue:
+		return hex.EncodeToString(v.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]string, 0, len(v.ArrayValue.GetValues()))
+		for _, e := range v.ArrayValue.GetValues() {
+			values = append(values, anyValueString(e))
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case *commonpb.AnyValue_KvlistValue:
+		return attributesString(v.KvlistValue.GetValues())
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+	otelpp "otlp-stack/pkg/opentelemetry"
+	"otlp-stack/pkg/opentelemetry/fakecollector"
+)
+
+const receiveStopTimeout = 5 * time.Second
+
+/*
+runReceive runs a local OTLP receiver printing the received traces, metrics
+and logs, to see what a service emits without the docker-compose stack:
+
+	otlp-stack receive -service my-service -json received.jsonl
+
+The received export requests can be appended to a file as OTLP JSON, one per
+line, the format of the file exporter of the OpenTelemetry Collector.
+*/
+func runReceive(args []string) error {
+	fs := flag.NewFlagSet("receive", flag.ContinueOnError)
+	grpcAddr := fs.String("grpc", "0.0.0.0:4317", "address of the OTLP gRPC receiver")
+	httpAddr := fs.String("http", "0.0.0.0:4318", "address of the OTLP HTTP receiver")
+	services := fs.String("service", "", "comma separated service names to print, all the services when empty")
+	jsonFile := fs.String("json", "", "file to append the received requests to as OTLP JSON, one per line")
+	attributes := fs.Bool("attributes", true, "print the resource, span, data point and log attributes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var out *jsonWriter
+	if *jsonFile != "" {
+		f, err := os.OpenFile(*jsonFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = &jsonWriter{f: f}
+	}
+
+	p := &printer{w: os.Stdout, attributes: *attributes}
+	filter := newServiceFilter(splitList(*services))
+
+	c, err := fakecollector.Start(
+		fakecollector.WithGRPCAddress(*grpcAddr),
+		fakecollector.WithHTTPAddress(*httpAddr),
+		fakecollector.WithoutStore(),
+		fakecollector.WithOnReceive(func(_ fakecollector.Signal, req proto.Message) {
+			req, ok := filter.apply(req)
+			if !ok {
+				return
+			}
+
+			p.print(req)
+
+			if out != nil {
+				if err := out.write(req); err != nil {
+					fmt.Fprintf(os.Stderr, "writing %s: %v\n", *jsonFile, err)
+				}
+			}
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "receiving OTLP on grpc %s and http %s, press Ctrl+C to stop\n", c.GRPCEndpoint(), c.HTTPEndpoint())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), receiveStopTimeout)
+	defer cancel()
+
+	return c.Stop(stopCtx)
+}
+
+// jsonWriter appends the export requests to f as OTLP JSON, one per line.
+type jsonWriter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (w *jsonWriter) write(req proto.Message) error {
+	b, err := otelpp.MarshalJSON(req)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err = w.f.Write(append(b, '\n'))
+	return err
+}
+
+// serviceFilter keeps the resources of the services, all the resources when
+// services is empty.
+type serviceFilter struct {
+	services map[string]bool
+}
+
+func newServiceFilter(services []string) serviceFilter {
+	f := serviceFilter{}
+	if len(services) == 0 {
+		return f
+	}
+
+	f.services = make(map[string]bool, len(services))
+	for _, s := range services {
+		f.services[s] = true
+	}
+
+	return f
+}
+
+// apply returns the export request with the resources of the services, and
+// false when none is left.
+func (f serviceFilter) apply(req proto.Message) (proto.Message, bool) {
+	if f.services == nil {
+		return req, true
+	}
+
+	switch r := req.(type) {
+	case *coltracepb.ExportTraceServiceRequest:
+		var rs []*tracepb.ResourceSpans
+		for _, res := range r.ResourceSpans {
+			if f.services[fakecollector.ServiceName(res.Resource)] {
+				rs = append(rs, res)
+			}
+		}
+		return &coltracepb.ExportTraceServiceRequest{ResourceSpans: rs}, len(rs) > 0
+	case *colmetricpb.ExportMetricsServiceRequest:
+		var rm []*metricpb.ResourceMetrics
+		for _, res := range r.ResourceMetrics {
+			if f.services[fakecollector.ServiceName(res.Resource)] {
+				rm = append(rm, res)
+			}
+		}
+		return &colmetricpb.ExportMetricsServiceRequest{ResourceMetrics: rm}, len(rm) > 0
+	case *collogspb.ExportLogsServiceRequest:
+		var rl []*logspb.ResourceLogs
+		for _, res := range r.ResourceLogs {
+			if f.services[fakecollector.ServiceName(res.Resource)] {
+				rl = append(rl, res)
+			}
+		}
+		return &collogspb.ExportLogsServiceRequest{ResourceLogs: rl}, len(rl) > 0
+	default:
+		return nil, false
+	}
+}
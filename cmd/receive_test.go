@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPrinter_Traces(t *testing.T) {
+	traceID := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	otherTraceID := []byte{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}
+	span := func(traceID []byte, id, parent byte, name string, start, end uint64) *tracepb.Span {
+		s := &tracepb.Span{
+			TraceId:           traceID,
+			SpanId:            []byte{0, 0, 0, 0, 0, 0, 0, id},
+			Name:              name,
+			Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+			StartTimeUnixNano: start * 1e6,
+			EndTimeUnixNano:   end * 1e6,
+		}
+		if parent != 0 {
+			s.ParentSpanId = []byte{0, 0, 0, 0, 0, 0, 0, parent}
+		}
+		return s
+	}
+
+	root := span(traceID, 1, 0, "GET /users", 0, 10)
+	root.Kind = tracepb.Span_SPAN_KIND_SERVER
+	root.Attributes = []*commonpb.KeyValue{stringKeyValue("http.route", "/users")}
+	query := span(traceID, 3, 1, "db query", 1, 3)
+	query.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "timeout"}
+	query.Events = []*tracepb.Span_Event{{Name: "exception"}}
+
+	// the children are sorted by start time, the span of a parent exported
+	// in another request is a root
+	req := &coltracepb.ExportTraceServiceRequest{ResourceSpans: []*tracepb.ResourceSpans{{
+		Resource: testResource("api"),
+		ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{
+			span(traceID, 4, 3, "scan", 1, 2),
+			query,
+			span(traceID, 2, 1, "cache get", 0, 1),
+			root,
+			span(otherTraceID, 5, 9, "orphan", 0, 1),
+		}}},
+	}}}
+
+	got := printed(t, true, req)
+	want := `resource {service.name=api}
+trace 0102030405060708090a0b0c0d0e0f10
+└─ GET /users [SERVER] 10ms {http.route=/users}
+   ├─ cache get [INTERNAL] 1ms
+   └─ db query [INTERNAL] 2ms ERROR "timeout"
+      • exception
+      └─ scan [INTERNAL] 1ms
+trace 02020202020202020202020202020202
+└─ orphan [INTERNAL] 1ms
+`
+	if got != want {
+		t.Errorf("printed:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrinter_Metrics(t *testing.T) {
+	sum := 3.5
+	req := &colmetricpb.ExportMetricsServiceRequest{ResourceMetrics: []*metricpb.ResourceMetrics{{
+		Resource: testResource("api"),
+		ScopeMetrics: []*metricpb.ScopeMetrics{{
+			Scope: &commonpb.InstrumentationScope{Name: "api", Version: "1.0"},
+			Metrics: []*metricpb.Metric{
+				{
+					Name: "requests",
+					Unit: "{request}",
+					Data: &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+						IsMonotonic:            true,
+						AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+						DataPoints: []*metricpb.NumberDataPoint{{
+							Attributes: []*commonpb.KeyValue{stringKeyValue("route", "/users")},
+							Value:      &metricpb.NumberDataPoint_AsInt{AsInt: 3},
+						}},
+					}},
+				},
+				{
+					Name: "latency",
+					Data: &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+						AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+						DataPoints: []*metricpb.HistogramDataPoint{{
+							Count:          2,
+							Sum:            &sum,
+							ExplicitBounds: []float64{1, 5},
+							BucketCounts:   []uint64{1, 0, 1},
+						}},
+					}},
+				},
+			},
+		}},
+	}}}
+
+	got := printed(t, false, req)
+	want := `scope api@1.0
+  requests (counter, delta) unit={request}
+    3
+  latency (histogram, cumulative)
+    count=2 sum=3.5 le1=1 le+Inf=1
+`
+	if got != want {
+		t.Errorf("printed:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrinter_Logs(t *testing.T) {
+	req := &collogspb.ExportLogsServiceRequest{ResourceLogs: []*logspb.ResourceLogs{{
+		Resource: testResource("api"),
+		ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{
+			ObservedTimeUnixNano: 1,
+			SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+			Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "failed"}},
+			TraceId:              []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			SpanId:               []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		}}}},
+	}}}
+
+	got := printed(t, false, req)
+	if want := " ERROR failed trace=0102030405060708090a0b0c0d0e0f10 span=0102030405060708\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("printed %q, want the suffix %q", got, want)
+	}
+}
+
+func TestServiceFilter(t *testing.T) {
+	req := &coltracepb.ExportTraceServiceRequest{ResourceSpans: []*tracepb.ResourceSpans{
+		{Resource: testResource("api")},
+		{Resource: testResource("worker")},
+		{Resource: testResource("billing")},
+	}}
+
+	tests := []struct {
+		name     string
+		services []string
+		want     []string
+	}{
+		{name: "all", want: []string{"api", "worker", "billing"}},
+		{name: "some", services: []string{"billing", "api"}, want: []string{"api", "billing"}},
+		{name: "none", services: []string{"gateway"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, ok := newServiceFilter(tt.services).apply(req)
+			if ok != (len(tt.want) > 0) {
+				t.Fatalf("apply kept = %v, want %v", ok, len(tt.want) > 0)
+			}
+
+			var got []string
+			for _, rs := range filtered.(*coltracepb.ExportTraceServiceRequest).ResourceSpans {
+				got = append(got, rs.Resource.Attributes[0].Value.GetStringValue())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("services = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the logs and metrics are filtered the same way
+	logs := &collogspb.ExportLogsServiceRequest{ResourceLogs: []*logspb.ResourceLogs{{Resource: testResource("worker")}}}
+	if _, ok := newServiceFilter([]string{"api"}).apply(logs); ok {
+		t.Error("logs of worker kept by the api filter")
+	}
+	metrics := &colmetricpb.ExportMetricsServiceRequest{ResourceMetrics: []*metricpb.ResourceMetrics{{Resource: testResource("api")}}}
+	if _, ok := newServiceFilter([]string{"api"}).apply(metrics); !ok {
+		t.Error("metrics of api dropped by the api filter")
+	}
+}
+
+// printed returns the text printed for req, without the first line, which
+// holds the time of the print.
+func printed(t *testing.T, attributes bool, req proto.Message) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	p := &printer{w: &b, attributes: attributes}
+	p.print(req)
+
+	header, rest, _ := strings.Cut(b.String(), "\n")
+	if !strings.HasSuffix(header, "service=api") {
+		t.Errorf("header = %q, want the service", header)
+	}
+	return rest
+}
+
+func testResource(service string) *resourcepb.Resource {
+	return &resourcepb.Resource{Attributes: []*commonpb.KeyValue{stringKeyValue("service.name", service)}}
+}
+
+func stringKeyValue(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
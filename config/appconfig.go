@@ -6,17 +6,34 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 	"log"
+	"time"
 )
 
 const fileConfig = ".env"
 
 type Config struct {
-	ServiceName string `mapstructure:"SERVICE_NAME"`
-	AppStage    string `mapstructure:"APP_STAGE"`
-	AppDev      bool   `mapstructure:"APP_DEV"`
-	HTTPPort    string `mapstructure:"HTTP_PORT"`
-	MetricHost  string `mapstructure:"OTEL_EXPORTER_METRIC_ENDPOINT"`
-	TraceHost   string `mapstructure:"OTEL_EXPORTER_TRACE_ENDPOINT"`
+	ServiceName    string `mapstructure:"SERVICE_NAME"`
+	ServiceVersion string `mapstructure:"SERVICE_VERSION"`
+	AppStage       string `mapstructure:"APP_STAGE"`
+	AppDev         bool   `mapstructure:"APP_DEV"`
+	HTTPPort       string `mapstructure:"HTTP_PORT"`
+	MetricHost     string `mapstructure:"OTEL_EXPORTER_METRIC_ENDPOINT"`
+	TraceHost      string `mapstructure:"OTEL_EXPORTER_TRACE_ENDPOINT"`
+	MetricProtocol string `mapstructure:"OTEL_EXPORTER_METRIC_PROTOCOL"`
+	TraceProtocol  string `mapstructure:"OTEL_EXPORTER_TRACE_PROTOCOL"`
+	LogHost        string `mapstructure:"OTEL_EXPORTER_LOG_ENDPOINT"`
+	LogProtocol    string `mapstructure:"OTEL_EXPORTER_LOG_PROTOCOL"`
+	TelemetryFile  string `mapstructure:"TELEMETRY_CONFIG_FILE"`
+
+	// ExportTimeout, e.g. 5s, and ExportCompression, gzip or none, are left
+	// to OTEL_EXPORTER_OTLP_TIMEOUT and OTEL_EXPORTER_OTLP_COMPRESSION when empty
+	ExportTimeout     time.Duration `mapstructure:"OTEL_EXPORTER_TIMEOUT"`
+	ExportCompression string        `mapstructure:"OTEL_EXPORTER_COMPRESSION"`
+
+	PrometheusEnabled        bool `mapstructure:"PROMETHEUS_ENABLED"`
+	PrometheusResourceLabels bool `mapstructure:"PROMETHEUS_RESOURCE_TO_TELEMETRY_CONVERSION"`
+
+	Telemetry Telemetry `mapstructure:"-"`
 }
 
 // Load the config from file or env to the Config struct
@@ -26,6 +43,7 @@ func Load(_ context.Context) (*Config, error) {
 
 	viper.SetDefault("HTTP_PORT", "8080")
 	viper.SetDefault("APP_STAGE", "DEV")
+	viper.SetDefault("TELEMETRY_CONFIG_FILE", "telemetry.yaml")
 
 	var cfg Config
 
@@ -41,6 +59,12 @@ func Load(_ context.Context) (*Config, error) {
 		return nil, fmt.Errorf("%v", err)
 	}
 
+	telemetry, err := loadTelemetry(cfg.TelemetryFile)
+	if err != nil {
+		return nil, fmt.Errorf("%v", err)
+	}
+	cfg.Telemetry = telemetry
+
 	return &cfg, nil
 }
 
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"io/fs"
+	"log"
+	"os"
+	otelpp "otlp-stack/pkg/opentelemetry"
+)
+
+// Telemetry is the telemetry configuration read from the YAML file set in
+// TELEMETRY_CONFIG_FILE, for the settings that do not fit in env variables
+type Telemetry struct {
+	Sampling Sampling `mapstructure:"sampling"`
+	Metrics  Metrics  `mapstructure:"metrics"`
+}
+
+// Sampling - ordered rules evaluated for the root spans, see otelpp.RuleSampler
+type Sampling struct {
+	Rules []otelpp.SamplingRule `mapstructure:"rules"`
+}
+
+// Metrics - ordered view rules changing the metric streams, see otelpp.ViewRule,
+// and distinct attribute sets per instrument, see otelpp.CardinalityLimitConfig
+type Metrics struct {
+	Views            []otelpp.ViewRule `mapstructure:"views"`
+	CardinalityLimit int               `mapstructure:"cardinality_limit"`
+}
+
+// loadTelemetry reads the telemetry configuration file, a missing file
+// results in an empty configuration, an unreadable or invalid file in an error
+func loadTelemetry(file string) (Telemetry, error) {
+	var telemetry Telemetry
+
+	if file == "" {
+		return telemetry, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+
+	if err := v.ReadInConfig(); err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) && os.IsNotExist(pathErr) {
+			log.Println("unable find telemetry configuration file")
+			return telemetry, nil
+		}
+		return telemetry, fmt.Errorf("%v", err)
+	}
+
+	if err := v.UnmarshalExact(&telemetry); err != nil {
+		return telemetry, fmt.Errorf("%v", err)
+	}
+
+	return telemetry, nil
+}
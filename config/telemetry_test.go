@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTelemetry(t *testing.T, name, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestLoadTelemetry(t *testing.T) {
+	file := writeTelemetry(t, "telemetry.yaml", `
+sampling:
+  rules:
+    - name: "GET /health*"
+      drop: true
+metrics:
+  cardinality_limit: 100
+`)
+
+	telemetry, err := loadTelemetry(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(telemetry.Sampling.Rules) != 1 || telemetry.Sampling.Rules[0].Name != "GET /health*" || !telemetry.Sampling.Rules[0].Drop {
+		t.Errorf("sampling rules = %+v", telemetry.Sampling.Rules)
+	}
+	if telemetry.Metrics.CardinalityLimit != 100 {
+		t.Errorf("cardinality limit = %d, want 100", telemetry.Metrics.CardinalityLimit)
+	}
+}
+
+func TestLoadTelemetry_MissingFile(t *testing.T) {
+	telemetry, err := loadTelemetry(filepath.Join(t.TempDir(), "telemetry.yaml"))
+	if err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+
+	if len(telemetry.Sampling.Rules) != 0 || len(telemetry.Metrics.Views) != 0 {
+		t.Errorf("missing file results in %+v, want an empty configuration", telemetry)
+	}
+}
+
+func TestLoadTelemetry_Errors(t *testing.T) {
+	tests := map[string]string{
+		"invalid YAML":  writeTelemetry(t, "telemetry.yaml", "sampling: [rules"),
+		"unknown field": writeTelemetry(t, "telemetry.yaml", "sampling:\n  rule: []\n"),
+		"unsupported":   writeTelemetry(t, "telemetry.txt", "sampling: {}"),
+		"directory":     filepath.Join(t.TempDir(), "telemetry.yaml"),
+	}
+	if err := os.Mkdir(tests["directory"], 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadTelemetry(file); err == nil {
+				t.Errorf("loadTelemetry(%s) succeeded", file)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package telemetry
 
 import (
 	"context"
+	"fmt"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/contrib/instrumentation/host"
@@ -9,6 +10,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"otlp-stack/config"
 	otelpp "otlp-stack/pkg/opentelemetry"
+	"strings"
 	"time"
 )
 
@@ -22,12 +24,14 @@ const (
 type Instrument interface {
 	StartRootSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span)
 	Metric() otelpp.Meter
+	Logging() otelpp.Logging
 }
 type instrument struct {
-	config *config.Config
-	log    logr.Logger
-	trace  otelpp.Telemetry
-	metric otelpp.Meter
+	config  *config.Config
+	log     logr.Logger
+	trace   otelpp.Telemetry
+	metric  otelpp.Meter
+	logging otelpp.Logging
 }
 
 func (i instrument) StartRootSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
@@ -43,6 +47,22 @@ func (i instrument) Metric() otelpp.Meter {
 	return i.metric
 }
 
+// Logging returns the OTLP logs pipeline, nil when no log endpoint is configured
+func (i instrument) Logging() otelpp.Logging {
+	return i.logging
+}
+
+// New returns the Instrument of the providers, used to test the code taking
+// an Instrument, e.g. with the Telemetry and Meter of otelpptest. logging may
+// be nil.
+func New(trace otelpp.Telemetry, metric otelpp.Meter, logging otelpp.Logging) Instrument {
+	return &instrument{
+		trace:   trace,
+		metric:  metric,
+		logging: logging,
+	}
+}
+
 func InitTelemetry(ctx context.Context, l logr.Logger, cfg *config.Config) (Instrument, error) {
 	appEnv, err := otelpp.EnvLevelFromString(cfg.AppStage)
 	if err != nil {
@@ -64,22 +84,24 @@ func InitTelemetry(ctx context.Context, l logr.Logger, cfg *config.Config) (Inst
 		}
 	}()
 
-	instrumentation.trace, instrumentation.metric, err = otelpp.NewGRPCProvider(ctxTimeout,
-		otelpp.WithAppEnv(appEnv),
-		otelpp.WithTraceEndpoint(cfg.TraceHost),
-		otelpp.WithTrace(),
-		otelpp.WithMetricEndpoint(cfg.MetricHost),
-		otelpp.WithMetric(),
-		otelpp.WithServiceName(cfg.ServiceName),
-		otelpp.WithInsecure(true),
-		otelpp.WithRetryDefault(),
-		otelpp.WithTimeout(otlTimeout),
-		otelpp.WithLogger(l),
-		otelpp.WithGzipCompression(true),
-	)
+	opts, err := providerOptions(l, cfg, appEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	instrumentation.trace, instrumentation.metric, err = otelpp.NewProvider(ctxTimeout, opts...)
 	if err != nil {
 		return nil, errors.Wrap(err, err.Error())
 	}
+
+	logging, err := otelpp.NewLoggerProvider(ctxTimeout, opts...)
+	switch {
+	case err == nil:
+		instrumentation.logging = logging
+	case !errors.Is(err, otelpp.ErrMissingLogConfig):
+		return nil, errors.Wrap(err, err.Error())
+	}
+
 	if err = metricProvider(); err != nil {
 		return nil, errors.Wrap(err, err.Error())
 	}
@@ -87,6 +109,110 @@ func InitTelemetry(ctx context.Context, l logr.Logger, cfg *config.Config) (Inst
 	return &instrumentation, nil
 }
 
+// ProviderOptions returns the otelpp options of the application configured
+// by cfg, used by the commands creating their own providers.
+func ProviderOptions(l logr.Logger, cfg *config.Config) ([]otelpp.OptionProvider, error) {
+	appEnv, err := otelpp.EnvLevelFromString(cfg.AppStage)
+	if err != nil {
+		return nil, err
+	}
+
+	return providerOptions(l, cfg, appEnv)
+}
+
+// providerOptions returns the otelpp options of the application. The values
+// missing from cfg are left to the standard OTEL_* environment variables
+// read by otelpp.
+func providerOptions(l logr.Logger, cfg *config.Config, appEnv otelpp.EnvLevel) ([]otelpp.OptionProvider, error) {
+	opts := []otelpp.OptionProvider{
+		otelpp.WithAppEnv(appEnv),
+		otelpp.WithTrace(otelpp.WithSamplingRules(cfg.Telemetry.Sampling.Rules...)),
+		otelpp.WithMetric(
+			otelpp.WithMetricViewRules(cfg.Telemetry.Metrics.Views...),
+			otelpp.WithCardinalityLimit(otelpp.CardinalityLimitConfig{Limit: cfg.Telemetry.Metrics.CardinalityLimit}),
+		),
+		otelpp.WithRetryDefault(),
+		otelpp.WithLogger(l),
+		otelpp.WithHostDetector(),
+		otelpp.WithProcessDetector(),
+		otelpp.WithOSDetector(),
+		otelpp.WithContainerDetector(),
+		otelpp.WithKubernetesDetector(),
+	}
+
+	if cfg.ServiceName != "" {
+		opts = append(opts, otelpp.WithServiceName(cfg.ServiceName))
+	}
+
+	if cfg.ExportTimeout > 0 {
+		opts = append(opts, otelpp.WithTimeout(cfg.ExportTimeout))
+	}
+
+	switch strings.ToLower(cfg.ExportCompression) {
+	case "":
+	case "gzip":
+		opts = append(opts, otelpp.WithGzipCompression(true))
+	case "none":
+		opts = append(opts, otelpp.WithGzipCompression(false))
+	default:
+		return nil, fmt.Errorf("invalid export compression %q, use gzip or none", cfg.ExportCompression)
+	}
+
+	if cfg.ServiceVersion != "" {
+		opts = append(opts, otelpp.WithServiceVersion(cfg.ServiceVersion))
+	}
+
+	// the custom endpoints are host:port of a local collector without TLS
+	if cfg.TraceHost != "" || cfg.MetricHost != "" || cfg.LogHost != "" {
+		opts = append(opts, otelpp.WithInsecure(true))
+	}
+
+	if cfg.TraceHost != "" {
+		opts = append(opts, otelpp.WithTraceEndpoint(cfg.TraceHost))
+	}
+
+	if cfg.MetricHost != "" {
+		opts = append(opts, otelpp.WithMetricEndpoint(cfg.MetricHost))
+	}
+
+	if cfg.LogHost != "" {
+		opts = append(opts, otelpp.WithLogEndpoint(cfg.LogHost))
+	}
+
+	if cfg.TraceProtocol != "" {
+		protocol, err := otelpp.ProtocolFromString(cfg.TraceProtocol)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otelpp.WithTrace(otelpp.WithTraceProtocol(protocol)))
+	}
+
+	if cfg.MetricProtocol != "" {
+		protocol, err := otelpp.ProtocolFromString(cfg.MetricProtocol)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otelpp.WithMetric(otelpp.WithMetricProtocol(protocol)))
+	}
+
+	// scraped on /metrics of the application, see StartGin
+	if cfg.PrometheusEnabled {
+		opts = append(opts, otelpp.WithMetric(otelpp.WithPrometheus(otelpp.PrometheusConfig{
+			ResourceToTelemetryConversion: cfg.PrometheusResourceLabels,
+		})))
+	}
+
+	if cfg.LogProtocol != "" {
+		protocol, err := otelpp.ProtocolFromString(cfg.LogProtocol)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otelpp.WithLog(otelpp.WithLogProtocol(protocol)))
+	}
+
+	return opts, nil
+}
+
 func metricProvider() error {
 	if err := host.Start(); err != nil {
 		return errors.Wrap(err, err.Error())
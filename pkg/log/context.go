@@ -0,0 +1,113 @@
+package log
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the fields added by FromContext and WithContext.
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// FromContext returns the global Logger with the trace_id, span_id and
+// trace_flags fields of the active span in ctx.
+func FromContext(ctx context.Context) logr.Logger {
+	return WithContext(ctx, Logger)
+}
+
+// WithContext returns l with the trace_id, span_id and trace_flags fields
+// of the active span in ctx. When Init was called with WithSpanEvents, the
+// error-level logs are also recorded as events of that span.
+// l is returned unchanged when ctx has no valid span.
+func WithContext(ctx context.Context, l logr.Logger) logr.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || l.GetSink() == nil {
+		return l
+	}
+
+	l = l.WithValues(
+		TraceIDKey, sc.TraceID().String(),
+		SpanIDKey, sc.SpanID().String(),
+		TraceFlagsKey, sc.TraceFlags().String(),
+	)
+
+	if spanEvents {
+		l = l.WithSink(newSpanEventSink(l.GetSink(), trace.SpanFromContext(ctx)))
+	}
+
+	return l
+}
+
+// spanEventSink is a logr.LogSink wrapper that records the error-level
+// logs as events of a span before forwarding them.
+type spanEventSink struct {
+	sink logr.LogSink
+	span trace.Span
+}
+
+func newSpanEventSink(sink logr.LogSink, span trace.Span) logr.LogSink {
+	// The wrapper adds a frame between the caller and the sink.
+	if cd, ok := sink.(logr.CallDepthLogSink); ok {
+		sink = cd.WithCallDepth(1)
+	}
+	return &spanEventSink{sink: sink, span: span}
+}
+
+func (s *spanEventSink) Init(info logr.RuntimeInfo) {
+	s.sink.Init(info)
+}
+
+func (s *spanEventSink) Enabled(level int) bool {
+	return s.sink.Enabled(level)
+}
+
+func (s *spanEventSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.sink.Info(level, msg, keysAndValues...)
+}
+
+func (s *spanEventSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if s.span.IsRecording() {
+		attrs := append([]attribute.KeyValue{attribute.String("log.message", msg)}, toAttributes(keysAndValues)...)
+		if err != nil {
+			s.span.RecordError(err, trace.WithAttributes(attrs...))
+		} else {
+			s.span.AddEvent("log", trace.WithAttributes(attrs...))
+		}
+	}
+
+	s.sink.Error(err, msg, keysAndValues...)
+}
+
+func (s *spanEventSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &spanEventSink{sink: s.sink.WithValues(keysAndValues...), span: s.span}
+}
+
+func (s *spanEventSink) WithName(name string) logr.LogSink {
+	return &spanEventSink{sink: s.sink.WithName(name), span: s.span}
+}
+
+func (s *spanEventSink) WithCallDepth(depth int) logr.LogSink {
+	if cd, ok := s.sink.(logr.CallDepthLogSink); ok {
+		return &spanEventSink{sink: cd.WithCallDepth(depth), span: s.span}
+	}
+	return s
+}
+
+func toAttributes(keysAndValues []interface{}) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		attrs = append(attrs, toAttribute(key, keysAndValues[i+1]))
+	}
+	return attrs
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithContext(t *testing.T) {
+	setSpanEvents(t, true)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "operation")
+	sc := span.SpanContext()
+
+	logging := &fakeLogging{}
+	l := WithContext(ctx, zapr.NewLogger(zap.New(NewOTLPCore(logging, zapcore.InfoLevel))))
+
+	l.Info("working", "step", 1)
+	l.Error(errors.New("boom"), "failed", "step", 2)
+	span.End()
+
+	// the log records are correlated with the span
+	records := logging.emitted()
+	if len(records) != 2 {
+		t.Fatalf("emitted %d records, want 2", len(records))
+	}
+	for _, r := range records {
+		if r.TraceID != sc.TraceID() || r.SpanID != sc.SpanID() || r.TraceFlags != sc.TraceFlags() {
+			t.Errorf("%s: trace context = %s %s %s, want %s %s %s", r.Body,
+				r.TraceID, r.SpanID, r.TraceFlags, sc.TraceID(), sc.SpanID(), sc.TraceFlags())
+		}
+		for _, kv := range r.Attributes {
+			if kv.Key == TraceIDKey || kv.Key == SpanIDKey || kv.Key == TraceFlagsKey {
+				t.Errorf("%s: attribute %s, want it moved to the trace context", r.Body, kv.Key)
+			}
+		}
+	}
+
+	// the error log is recorded as an event of the span
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("ended %d spans, want 1", len(ended))
+	}
+	events := ended[0].Events()
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("events = %v, want one exception", events)
+	}
+	attrs := attributeMap(events[0].Attributes)
+	if attrs["log.message"] != attribute.StringValue("failed") || attrs["step"] != attribute.IntValue(2) ||
+		attrs["exception.message"] != attribute.StringValue("boom") {
+		t.Errorf("event attributes = %v", events[0].Attributes)
+	}
+}
+
+func TestWithContext_SpanEventsDisabled(t *testing.T) {
+	setSpanEvents(t, false)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "operation")
+	l := WithContext(ctx, zapr.NewLogger(zap.NewNop()))
+	l.Error(errors.New("boom"), "failed")
+	span.End()
+
+	if events := recorder.Ended()[0].Events(); len(events) != 0 {
+		t.Errorf("events = %v, want none", events)
+	}
+}
+
+func TestFromContext_NoSpan(t *testing.T) {
+	logging := &fakeLogging{}
+	previous := Logger
+	Logger = zapr.NewLogger(zap.New(NewOTLPCore(logging, zapcore.InfoLevel)))
+	t.Cleanup(func() { Logger = previous })
+
+	FromContext(context.Background()).Info("no span")
+
+	records := logging.emitted()
+	if len(records) != 1 || records[0].TraceID.IsValid() || len(records[0].Attributes) != 0 {
+		t.Errorf("records = %+v, want one without trace context", records)
+	}
+
+	if l := WithContext(context.Background(), logr.Discard()); l.GetSink() != nil {
+		t.Error("WithContext changed the logger without a span")
+	}
+}
+
+func setSpanEvents(t *testing.T, enabled bool) {
+	t.Helper()
+
+	previous := spanEvents
+	spanEvents = enabled
+	t.Cleanup(func() { spanEvents = previous })
+}
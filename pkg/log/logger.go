@@ -6,11 +6,15 @@ import (
 	"github.com/go-logr/zapr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"os"
+	otelpp "otlp-stack/pkg/opentelemetry"
 )
 
 type Options struct {
 	Level       zapcore.Level
 	Development bool
+	Logging     otelpp.Logging
+	SpanEvents  bool
 }
 
 type Option func(*Options)
@@ -27,8 +31,24 @@ func WithDevelopment(development bool) Option {
 	}
 }
 
+// WithOTLP - tee the log records to the OTLP logs pipeline, next to stdout
+func WithOTLP(logging otelpp.Logging) Option {
+	return func(o *Options) {
+		o.Logging = logging
+	}
+}
+
+// WithSpanEvents - record the error-level logs of FromContext and WithContext loggers as span events
+func WithSpanEvents(spanEvents bool) Option {
+	return func(o *Options) {
+		o.SpanEvents = spanEvents
+	}
+}
+
 var Logger logr.Logger
 
+var spanEvents bool
+
 func Init(opts ...Option) logr.Logger {
 	options := Options{
 		Level:       zap.InfoLevel,
@@ -48,11 +68,20 @@ func Init(opts ...Option) logr.Logger {
 
 	zapConfig.Level.SetLevel(options.Level)
 
-	zapLog, err := zapConfig.Build()
+	var zapOpts []zap.Option
+	if options.Logging != nil {
+		zapOpts = append(zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, NewOTLPCore(options.Logging, zapConfig.Level))
+		}))
+	}
+
+	zapLog, err := zapConfig.Build(zapOpts...)
 	if err != nil {
-		fmt.Errorf("%v", err)
+		fmt.Fprintf(os.Stderr, "failed to build logger: %v\n", err)
+		zapLog = zap.NewNop()
 	}
 
+	spanEvents = options.SpanEvents
 	Logger = zapr.NewLogger(zapLog)
 	return Logger
 }
@@ -0,0 +1,191 @@
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+	otelpp "otlp-stack/pkg/opentelemetry"
+	"otlp-stack/pkg/opentelemetry/semconv"
+)
+
+// otlpCore is a zapcore.Core that sends every entry to an otelpp.Logging
+// pipeline, so the records reach the collector next to traces and metrics.
+type otlpCore struct {
+	zapcore.LevelEnabler
+	logging otelpp.Logging
+	fields  []zapcore.Field
+}
+
+// NewOTLPCore creates a zapcore.Core that exports the entries enabled by
+// level through logging.
+func NewOTLPCore(logging otelpp.Logging, level zapcore.LevelEnabler) zapcore.Core {
+	return &otlpCore{
+		LevelEnabler: level,
+		logging:      logging,
+	}
+}
+
+func (c *otlpCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *otlpCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *otlpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	record := otelpp.LogRecord{
+		Timestamp:    entry.Time,
+		Severity:     toSeverity(entry.Level),
+		SeverityText: entry.Level.CapitalString(),
+		Body:         entry.Message,
+	}
+	extractTraceFields(&record, enc.Fields)
+
+	attrs := make([]attribute.KeyValue, 0, len(enc.Fields)+2)
+	if entry.LoggerName != "" {
+		attrs = append(attrs, attribute.String("logger", entry.LoggerName))
+	}
+	if entry.Caller.Defined {
+		attrs = append(attrs,
+			semconv.CodeFilepath(entry.Caller.File),
+			semconv.CodeLineNumber(entry.Caller.Line),
+		)
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, toAttribute(k, enc.Fields[k]))
+	}
+
+	record.Attributes = attrs
+	c.logging.Emit(context.Background(), record)
+
+	return nil
+}
+
+func (c *otlpCore) Sync() error {
+	return nil
+}
+
+// extractTraceFields moves the fields added by WithContext to the trace
+// context of the record, so the backend can link the log to the span.
+func extractTraceFields(record *otelpp.LogRecord, fields map[string]interface{}) {
+	traceID, _ := fields[TraceIDKey].(string)
+	spanID, _ := fields[SpanIDKey].(string)
+
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		return
+	}
+
+	record.TraceID = tid
+	record.SpanID = sid
+	if flags, ok := fields[TraceFlagsKey].(string); ok {
+		if b, err := hex.DecodeString(flags); err == nil && len(b) == 1 {
+			record.TraceFlags = trace.TraceFlags(b[0])
+		}
+	}
+
+	delete(fields, TraceIDKey)
+	delete(fields, SpanIDKey)
+	delete(fields, TraceFlagsKey)
+}
+
+func toSeverity(level zapcore.Level) otelpp.Severity {
+	switch {
+	case level < zapcore.DebugLevel:
+		return otelpp.SeverityTrace
+	case level == zapcore.DebugLevel:
+		return otelpp.SeverityDebug
+	case level == zapcore.InfoLevel:
+		return otelpp.SeverityInfo
+	case level == zapcore.WarnLevel:
+		return otelpp.SeverityWarn
+	case level == zapcore.ErrorLevel:
+		return otelpp.SeverityError
+	default:
+		return otelpp.SeverityFatal
+	}
+}
+
+func toAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int8:
+		return attribute.Int64(key, int64(v))
+	case int16:
+		return attribute.Int64(key, int64(v))
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case int64:
+		return attribute.Int64(key, v)
+	case uint8:
+		return attribute.Int64(key, int64(v))
+	case uint16:
+		return attribute.Int64(key, int64(v))
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case uint:
+		return uintAttribute(key, uint64(v))
+	case uint64:
+		return uintAttribute(key, v)
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case time.Time:
+		return attribute.String(key, v.Format(time.RFC3339Nano))
+	case time.Duration:
+		return attribute.String(key, v.String())
+	case []string:
+		return attribute.StringSlice(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+// uintAttribute keeps v as an int64 attribute when it fits, OTLP has no
+// unsigned integer value, and as its decimal string otherwise.
+func uintAttribute(key string, v uint64) attribute.KeyValue {
+	if v > math.MaxInt64 {
+		return attribute.String(key, strconv.FormatUint(v, 10))
+	}
+	return attribute.Int64(key, int64(v))
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	otelpp "otlp-stack/pkg/opentelemetry"
+)
+
+func TestOTLPCore_Write(t *testing.T) {
+	logging := &fakeLogging{}
+	logger := zap.New(NewOTLPCore(logging, zapcore.InfoLevel)).Named("api").With(zap.String("tenant", "a"))
+
+	logger.Debug("disabled")
+	logger.Warn("hello",
+		zap.Int("count", 3),
+		zap.Uint("small", 7),
+		zap.Uint64("big", math.MaxUint64),
+		zap.Duration("took", time.Second),
+	)
+
+	records := logging.emitted()
+	if len(records) != 1 {
+		t.Fatalf("emitted %d records, want 1", len(records))
+	}
+
+	r := records[0]
+	if r.Body != "hello" || r.Severity != otelpp.SeverityWarn || r.SeverityText != "WARN" {
+		t.Errorf("record = %q %d %q, want hello WARN", r.Body, r.Severity, r.SeverityText)
+	}
+	if r.TraceID.IsValid() {
+		t.Errorf("trace ID = %s, want none", r.TraceID)
+	}
+
+	want := map[string]attribute.Value{
+		"logger": attribute.StringValue("api"),
+		"tenant": attribute.StringValue("a"),
+		"count":  attribute.Int64Value(3),
+		"small":  attribute.Int64Value(7),
+		"big":    attribute.StringValue("18446744073709551615"),
+		"took":   attribute.StringValue("1s"),
+	}
+	got := attributeMap(r.Attributes)
+	if len(got) != len(want) {
+		t.Errorf("attributes = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("attribute %s = %v, want %v", k, got[k].Emit(), v.Emit())
+		}
+	}
+}
+
+func TestToAttribute(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  attribute.Value
+	}{
+		{"s", attribute.StringValue("s")},
+		{true, attribute.BoolValue(true)},
+		{int8(-8), attribute.Int64Value(-8)},
+		{int64(-64), attribute.Int64Value(-64)},
+		{uint8(8), attribute.Int64Value(8)},
+		{uint32(32), attribute.Int64Value(32)},
+		{uint(42), attribute.Int64Value(42)},
+		{uint64(math.MaxInt64), attribute.Int64Value(math.MaxInt64)},
+		{uint64(math.MaxInt64 + 1), attribute.StringValue("9223372036854775808")},
+		{float32(0.5), attribute.Float64Value(0.5)},
+		{time.Unix(0, 0).UTC(), attribute.StringValue("1970-01-01T00:00:00Z")},
+		{[]string{"a", "b"}, attribute.StringSliceValue([]string{"a", "b"})},
+		{struct{ A int }{1}, attribute.StringValue("{1}")},
+	}
+
+	for _, tt := range tests {
+		if got := toAttribute("k", tt.value); got.Value != tt.want {
+			t.Errorf("toAttribute(%T %v) = %s %v, want %s %v", tt.value, tt.value, got.Value.Type(), got.Value.Emit(), tt.want.Type(), tt.want.Emit())
+		}
+	}
+}
+
+func attributeMap(attrs []attribute.KeyValue) map[string]attribute.Value {
+	m := make(map[string]attribute.Value, len(attrs))
+	for _, kv := range attrs {
+		m[string(kv.Key)] = kv.Value
+	}
+	return m
+}
+
+// fakeLogging records the emitted log records.
+type fakeLogging struct {
+	mu      sync.Mutex
+	records []otelpp.LogRecord
+}
+
+func (l *fakeLogging) Emit(_ context.Context, record otelpp.LogRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = append(l.records, record)
+}
+
+func (l *fakeLogging) ForceFlush(context.Context) error { return nil }
+
+func (l *fakeLogging) Shutdown(context.Context) error { return nil }
+
+func (l *fakeLogging) emitted() []otelpp.LogRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]otelpp.LogRecord(nil), l.records...)
+}
@@ -0,0 +1,244 @@
+package otelpp
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+const (
+	defaultCardinalityLimit = 2000
+	minCardinalityLimit     = 2
+
+	metricCardinalityOverflows = "otelpp.metric.cardinality.overflows"
+)
+
+var ErrCardinalityLimitReached = errors.New("metric cardinality limit reached")
+
+// OverflowAttribute is the only attribute of the measurements folded into
+// the overflow series by the cardinality limit.
+var OverflowAttribute = attribute.Bool("otel.metric.overflow", true)
+
+/*
+CardinalityLimitConfig - configuration of the cardinality limit of the synchronous instruments, the measurements
+with a new attribute set once an instrument reached its limit are recorded with otelpp.OverflowAttribute only,
+the first one is logged and they are counted by otelpp.metric.cardinality.overflows per instrument name
+Limit - distinct attribute sets per instrument name, including the overflow series, default value 2000,
+raised to 2 when lower so that one attribute set is recorded before the overflow series
+InstrumentLimits - limit of the instrument names overriding Limit
+The attribute sets are counted before the views, so an attribute removed by a view still counts, and they are
+never forgotten, also with the delta temporality. The observable instruments are not limited, nor are the
+instruments created from the global meter provider, e.g. by otelgin and the host and runtime metrics.
+*/
+type CardinalityLimitConfig struct {
+	Limit            int
+	InstrumentLimits map[string]int
+}
+
+func (c CardinalityLimitConfig) limit(name string) int {
+	l, ok := c.InstrumentLimits[name]
+	if !ok || l <= 0 {
+		l = c.Limit
+	}
+	if l <= 0 {
+		return defaultCardinalityLimit
+	}
+	if l < minCardinalityLimit {
+		return minCardinalityLimit
+	}
+	return l
+}
+
+// cardinalityLimiter keeps the attribute sets recorded by the instruments of
+// a provider, by instrument name.
+type cardinalityLimiter struct {
+	cfg    CardinalityLimitConfig
+	logger logr.Logger
+
+	mu          sync.Mutex
+	instruments map[string]*instrumentCardinality
+}
+
+func newCardinalityLimiter(cfg Config) *cardinalityLimiter {
+	return &cardinalityLimiter{
+		cfg:         *cfg.cardinalityLimit,
+		logger:      cfg.Logger,
+		instruments: make(map[string]*instrumentCardinality),
+	}
+}
+
+// instrument returns the attribute sets of the instrument name, shared by
+// the instruments created with the same name.
+func (l *cardinalityLimiter) instrument(name string) *instrumentCardinality {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.instruments[name]
+	if !ok {
+		c = &instrumentCardinality{
+			name:   name,
+			limit:  l.cfg.limit(name),
+			logger: l.logger,
+		}
+		l.instruments[name] = c
+	}
+
+	return c
+}
+
+// RegisterMetrics exports the measurements folded into the overflow series
+// through m.
+func (l *cardinalityLimiter) RegisterMetrics(m Meter) error {
+	overflows, err := m.Int64ObservableCounter(metricCardinalityOverflows,
+		instrument.WithDescription("measurements recorded in the overflow series after the cardinality limit of the instrument"),
+		instrument.WithUnit("{measurement}"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		for name, c := range l.instruments {
+			if n := c.overflowCount(); n > 0 {
+				o.ObserveInt64(overflows, n, attribute.String("instrument", name))
+			}
+		}
+		return nil
+	}, overflows)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	return nil
+}
+
+// instrumentCardinality is the set of the attribute sets of an instrument.
+// The known attribute sets are read without lock, the mutex only guards the
+// recording of the new ones.
+type instrumentCardinality struct {
+	name   string
+	limit  int
+	logger logr.Logger
+
+	known     sync.Map // attribute.Distinct
+	full      atomic.Bool
+	overflows atomic.Int64
+
+	mu   sync.Mutex
+	size int
+}
+
+// attributes returns attrs, or the overflow attribute when attrs is a new
+// attribute set and the limit is reached. The overflow series counts in the
+// limit.
+func (c *instrumentCardinality) attributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	var key attribute.Distinct
+	if len(attrs) == 0 {
+		key = attribute.EmptySet().Equivalent()
+	} else {
+		set := attribute.NewSet(attrs...)
+		key = set.Equivalent()
+	}
+
+	if _, ok := c.known.Load(key); ok {
+		return attrs
+	}
+
+	if !c.full.Load() && c.add(key) {
+		return attrs
+	}
+
+	if c.overflows.Add(1) == 1 {
+		c.logger.Error(ErrCardinalityLimitReached, "recording the new attribute sets in the overflow series",
+			"instrument", c.name, "limit", c.limit)
+	}
+
+	return []attribute.KeyValue{OverflowAttribute}
+}
+
+// add records the attribute set key, returning false when the limit is
+// reached.
+func (c *instrumentCardinality) add(key attribute.Distinct) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.known.Load(key); ok {
+		return true
+	}
+
+	if c.size >= c.limit-1 {
+		c.full.Store(true)
+		return false
+	}
+
+	c.known.Store(key, struct{}{})
+	c.size++
+
+	return true
+}
+
+func (c *instrumentCardinality) overflowCount() int64 {
+	return c.overflows.Load()
+}
+
+type limitedFloat64Counter struct {
+	instrument.Float64Counter
+	c *instrumentCardinality
+}
+
+func (i limitedFloat64Counter) Add(ctx context.Context, incr float64, attrs ...attribute.KeyValue) {
+	i.Float64Counter.Add(ctx, incr, i.c.attributes(attrs)...)
+}
+
+type limitedFloat64UpDownCounter struct {
+	instrument.Float64UpDownCounter
+	c *instrumentCardinality
+}
+
+func (i limitedFloat64UpDownCounter) Add(ctx context.Context, incr float64, attrs ...attribute.KeyValue) {
+	i.Float64UpDownCounter.Add(ctx, incr, i.c.attributes(attrs)...)
+}
+
+type limitedFloat64Histogram struct {
+	instrument.Float64Histogram
+	c *instrumentCardinality
+}
+
+func (i limitedFloat64Histogram) Record(ctx context.Context, incr float64, attrs ...attribute.KeyValue) {
+	i.Float64Histogram.Record(ctx, incr, i.c.attributes(attrs)...)
+}
+
+type limitedInt64Counter struct {
+	instrument.Int64Counter
+	c *instrumentCardinality
+}
+
+func (i limitedInt64Counter) Add(ctx context.Context, incr int64, attrs ...attribute.KeyValue) {
+	i.Int64Counter.Add(ctx, incr, i.c.attributes(attrs)...)
+}
+
+type limitedInt64UpDownCounter struct {
+	instrument.Int64UpDownCounter
+	c *instrumentCardinality
+}
+
+func (i limitedInt64UpDownCounter) Add(ctx context.Context, incr int64, attrs ...attribute.KeyValue) {
+	i.Int64UpDownCounter.Add(ctx, incr, i.c.attributes(attrs)...)
+}
+
+type limitedInt64Histogram struct {
+	instrument.Int64Histogram
+	c *instrumentCardinality
+}
+
+func (i limitedInt64Histogram) Record(ctx context.Context, incr int64, attrs ...attribute.KeyValue) {
+	i.Int64Histogram.Record(ctx, incr, i.c.attributes(attrs)...)
+}
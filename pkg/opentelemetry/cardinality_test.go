@@ -0,0 +1,42 @@
+package otelpp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestInstrumentCardinality_Attributes(t *testing.T) {
+	tests := []struct {
+		limit int
+		kept  int
+	}{
+		{limit: 3, kept: 3},
+		// raised to the minimum, /a is kept before the overflow series
+		{limit: 1, kept: 2},
+	}
+
+	for _, tt := range tests {
+		cfg := Config{Logger: logr.Discard()}
+		cfg.cardinalityLimit = &CardinalityLimitConfig{Limit: tt.limit}
+		c := newCardinalityLimiter(cfg).instrument("requests")
+
+		kept := 0
+		for _, route := range []string{"/a", "/b", "/c", "/a", "/d"} {
+			attrs := []attribute.KeyValue{attribute.String("route", route)}
+			if reflect.DeepEqual(c.attributes(attrs), attrs) {
+				kept++
+			}
+		}
+
+		// the known attribute sets are kept after the limit is reached
+		if kept != tt.kept {
+			t.Errorf("limit %d: kept %d measurements, want %d", tt.limit, kept, tt.kept)
+		}
+		if got, want := c.overflowCount(), int64(5-tt.kept); got != want {
+			t.Errorf("limit %d: overflows = %d, want %d", tt.limit, got, want)
+		}
+	}
+}
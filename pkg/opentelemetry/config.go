@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"github.com/go-logr/logr"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"strings"
 	"time"
 )
@@ -32,21 +34,35 @@ var (
 
 // Config struct defines the required fields to create tracer providers.
 type Config struct {
-	AppEnv         EnvLevel
-	TraceEndpoint  string
-	MetricEndpoint string
-	ServiceName    string
-	Logger         logr.Logger
+	AppEnv          EnvLevel
+	TraceEndpoint   string
+	MetricEndpoint  string
+	Protocol        Protocol
+	TraceProtocol   Protocol
+	MetricProtocol  Protocol
+	LogEndpoint     string
+	LogProtocol     Protocol
+	ServiceName     string
+	tracePath       string
+	metricPath      string
+	logPath         string
+	propagators     []propagation.TextMapPropagator
+	destinationName string
+	Logger          logr.Logger
 	JaegerConfig
 	OtlpConfig
 }
 
 func (c *Config) traceEnable() bool {
-	return c.TraceEndpoint != ""
+	return c.TraceEndpoint != "" || len(c.traceDestinations) > 0
 }
 
 func (c *Config) metricEnable() bool {
-	return c.MetricEndpoint != ""
+	return c.MetricEndpoint != "" || len(c.metricDestinations) > 0 || c.prometheus != nil
+}
+
+func (c *Config) logEnable() bool {
+	return c.LogEndpoint != ""
 }
 
 // JaegerConfig contains specific field for the Jaeger tracer provider
@@ -60,25 +76,67 @@ type OtlpConfig struct {
 	Timeout            time.Duration
 	GRPCBlockConn      bool
 	UseGzipCompression bool
+	TLSConfig
 	RetryConfig
+	ResourceConfig
+	spool      *SpoolConfig
+	signalEnvs map[string]*signalEnv
 	MetricConfig
 	TraceConfig
+	LogConfig
 }
 
 // MetricConfig - configuration for metric
+// MetricDestinations - additional collectors receiving a copy of the metrics
+// MetricFailover - secondary collector receiving the metrics while the primary endpoint is failing
+// TemporalitySelector - temporality of the OTLP exported metrics per instrument kind, default cumulative,
+// or OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE when set
+// AggregationSelector - default aggregation of the OTLP exported metrics per instrument kind, the views take precedence
+// CardinalityLimit - distinct attribute sets per synchronous instrument, see otelpp.CardinalityLimitConfig
+// Prometheus - pull endpoint exposing the metrics, see otelpp.PrometheusConfig
 // View is an override to the default behavior of the SDK. It defines how data
 // should be collected for certain instruments. use default otelpp.createMetricHistogramBucketView()
+// ViewRules - ordered rules changing the streams of the matching instruments over the views, see otelpp.ViewRule
 // SendIntervalMetric - default value 60s, defined at sdk metric.defaultInterval
+// ExportTimeoutMetric - default value Timeout, or 30s defined at sdk metric.defaultTimeout
 type MetricConfig struct {
-	sendIntervalMetric *time.Duration
-	reader             metric.Reader
-	views              []metric.View
+	sendIntervalMetric  *time.Duration
+	exportTimeoutMetric *time.Duration
+	reader              metric.Reader
+	views               []metric.View
+	viewRules           []ViewRule
+	metricDestinations  []destination
+	metricFailover      *failover
+	temporality         metric.TemporalitySelector
+	aggregation         metric.AggregationSelector
+	cardinalityLimit    *CardinalityLimitConfig
+	prometheus          *PrometheusConfig
 }
 
 // TraceConfig - configuration for trace
 // SendIntervalTrace - default value 5s, defined at sdk trace.DefaultScheduleDelay
+// ExportTimeoutTrace, MaxQueueSizeTrace, MaxExportBatchSizeTrace - defaults defined at sdk trace.BatchSpanProcessorOptions
+// Sampler - default value otelpp.DefaultSampler of the AppEnv, or OTEL_TRACES_SAMPLER when set
+// SamplingRules - evaluated before the sampler, which is used when no rule matches
+// TraceDestinations - additional collectors receiving a copy of the spans
+// TraceFailover - secondary collector receiving the spans while the primary endpoint is failing
+// TailSampling - buffers the sampled spans and exports the traces selected by its policies
 type TraceConfig struct {
-	sendIntervalTrace *time.Duration
+	sendIntervalTrace       *time.Duration
+	exportTimeoutTrace      *time.Duration
+	maxQueueSizeTrace       int
+	maxExportBatchSizeTrace int
+	sampler                 sdktrace.Sampler
+	samplingRules           []SamplingRule
+	tailSampling            *TailSamplingConfig
+	traceDestinations       []destination
+	traceFailover           *failover
+}
+
+// LogConfig - configuration for log
+// SendIntervalLog - default value 1s
+type LogConfig struct {
+	sendIntervalLog *time.Duration
 }
 
 func (c *OtlpConfig) ValidTimeout() bool {
@@ -87,11 +145,14 @@ func (c *OtlpConfig) ValidTimeout() bool {
 
 // RetryConfig contains the fields used by the internal package retry.Config,
 // defined by go.opentelemetry.io/otel/exporters/otlp/internal/retry
+// configured is set by WithRetry, so that WithRetry(WithRetryEnable(false))
+// disables the retry instead of using the defaults of the zero RetryConfig
 type RetryConfig struct {
 	Enabled         bool
 	InitialInterval time.Duration
 	MaxInterval     time.Duration
 	MaxElapsedTime  time.Duration
+	configured      bool
 }
 
 func hasMissingConfigInfo(cfg Config) bool {
@@ -118,21 +179,43 @@ func EnvLevelFromString(envLevel string) (EnvLevel, error) {
 	return INVALID, fmt.Errorf("invalid env level")
 }
 
-// trimEndpoint removes the 'http://' portion of the endpoint
+// trimEndpoint removes the 'http://' or 'https://' portion of the endpoint
 func trimEndpoint(endpoint string) string {
-	return strings.ReplaceAll(endpoint, "http://", "")
+	for _, scheme := range []string{"http://", "https://"} {
+		if len(endpoint) >= len(scheme) && strings.EqualFold(endpoint[:len(scheme)], scheme) {
+			return endpoint[len(scheme):]
+		}
+	}
+	return endpoint
 }
 
+// buildConfig applies the OTEL_* environment variables and then opts, the
+// invalid variables are logged with the configured Logger and ignored.
 func buildConfig(opts ...OptionProvider) Config {
 	cfg := Config{}
 
+	envErrs := applyEnv(&cfg)
+
 	for _, opt := range opts {
 		opt(&cfg)
 	}
 
+	for _, err := range envErrs {
+		cfg.Logger.Error(err, "ignoring invalid environment variable")
+	}
+
 	return cfg
 }
 
+// signalPath returns the URL path of a signal set by the environment, or
+// defaultPath.
+func signalPath(path, defaultPath string) string {
+	if path == "" {
+		return defaultPath
+	}
+	return path
+}
+
 func setErrorHandler(cfg Config) {
 	l := cfg.Logger
 
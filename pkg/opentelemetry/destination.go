@@ -0,0 +1,244 @@
+package otelpp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var (
+	ErrMissingDestinationEndpoint = errors.New("missing endpoint of the destination")
+	ErrInvalidDestinationName     = errors.New("invalid destination name, use a unique name of letters, digits, '.', '_' or '-'")
+)
+
+// destinationNamePattern matches the names usable as the spool directory of
+// the destination, excluding "." and "..".
+var destinationNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Compile-time check fanoutSpanProcessor implements sdktrace.SpanProcessor.
+var _ sdktrace.SpanProcessor = (*fanoutSpanProcessor)(nil)
+
+/*
+destination is an additional collector receiving a copy of the telemetry of
+a signal. Its configuration starts from the Config of the provider, without
+the endpoints, and opts are applied on top of it, so the protocol, headers,
+TLS, compression and retry can be changed for the destination only.
+
+The headers of the provider are not copied, as they usually hold the
+credentials of the primary collector, the destination sends the headers set
+with WithHeaders in opts only.
+
+The name identifies the destination in the metrics of the exporters and is
+the subdirectory of its spool, it must be unique per signal and made of
+letters, digits, '.', '_' or '-'.
+*/
+type destination struct {
+	name string
+	opts []OptionProvider
+}
+
+// config returns the Config of the destination.
+func (d destination) config(cfg Config) Config {
+	dc := endpointConfig(cfg, d.opts)
+	dc.destinationName = d.name
+
+	if dc.spool != nil {
+		spool := *dc.spool
+		spool.Directory = filepath.Join(spool.Directory, d.name)
+		dc.spool = &spool
+	}
+
+	return dc
+}
+
+// endpointConfig returns a copy of cfg without the endpoints, headers,
+// destinations, failovers, reader and per signal environment variables,
+// already resolved by signalConfig, with opts applied on top of it.
+func endpointConfig(cfg Config, opts []OptionProvider) Config {
+	ec := cfg
+	ec.TraceEndpoint, ec.tracePath = "", ""
+	ec.MetricEndpoint, ec.metricPath = "", ""
+	ec.Headers = nil
+	ec.traceDestinations = nil
+	ec.metricDestinations = nil
+	ec.traceFailover = nil
+	ec.metricFailover = nil
+	ec.reader = nil
+	ec.prometheus = nil
+	ec.signalEnvs = nil
+
+	for _, opt := range opts {
+		opt(&ec)
+	}
+
+	return ec
+}
+
+// traceDestinationConfigs returns the Config of the primary trace endpoint,
+// when set, and of each trace destination.
+func traceDestinationConfigs(cfg Config) ([]Config, error) {
+	var cfgs []Config
+
+	if cfg.TraceEndpoint != "" {
+		cfgs = append(cfgs, cfg)
+	}
+
+	if err := validateDestinationNames("trace", cfg.traceDestinations); err != nil {
+		return nil, err
+	}
+
+	for _, d := range cfg.traceDestinations {
+		dc := d.config(cfg)
+		if dc.TraceEndpoint == "" {
+			return nil, fmt.Errorf("%w: trace destination %q", ErrMissingDestinationEndpoint, d.name)
+		}
+		if p := dc.traceProtocol(); !p.valid() {
+			return nil, fmt.Errorf("%w: trace destination %q", ErrInvalidProtocol, d.name)
+		}
+		cfgs = append(cfgs, dc)
+	}
+
+	return cfgs, nil
+}
+
+// metricDestinationConfigs returns the Config of the primary metric
+// endpoint, when set, and of each metric destination.
+func metricDestinationConfigs(cfg Config) ([]Config, error) {
+	var cfgs []Config
+
+	if cfg.MetricEndpoint != "" {
+		cfgs = append(cfgs, cfg)
+	}
+
+	if err := validateDestinationNames("metric", cfg.metricDestinations); err != nil {
+		return nil, err
+	}
+
+	for _, d := range cfg.metricDestinations {
+		dc := d.config(cfg)
+		if dc.MetricEndpoint == "" {
+			return nil, fmt.Errorf("%w: metric destination %q", ErrMissingDestinationEndpoint, d.name)
+		}
+		if p := dc.metricProtocol(); !p.valid() || p == ProtocolJaeger {
+			return nil, fmt.Errorf("%w: metric destination %q", ErrInvalidMetricProtocol, d.name)
+		}
+		cfgs = append(cfgs, dc)
+	}
+
+	return cfgs, nil
+}
+
+// validateDestinationNames returns ErrInvalidDestinationName when a name of
+// destinations is empty, duplicated or unsafe as a path.
+func validateDestinationNames(signal string, destinations []destination) error {
+	names := make(map[string]struct{}, len(destinations))
+
+	for _, d := range destinations {
+		if !destinationNamePattern.MatchString(d.name) {
+			return fmt.Errorf("%w: %s destination %q", ErrInvalidDestinationName, signal, d.name)
+		}
+		if _, ok := names[d.name]; ok {
+			return fmt.Errorf("%w: duplicated %s destination %q", ErrInvalidDestinationName, signal, d.name)
+		}
+		names[d.name] = struct{}{}
+	}
+
+	return nil
+}
+
+// newTraceSpanProcessor returns a batch span processor per trace
+// destination, each one with its own exporter, and the exporters.
+func newTraceSpanProcessor(ctx context.Context, cfg Config) (sdktrace.SpanProcessor, []sdktrace.SpanExporter, error) {
+	cfgs, err := traceDestinationConfigs(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	processors := make([]sdktrace.SpanProcessor, 0, len(cfgs))
+	exps := make([]sdktrace.SpanExporter, 0, len(cfgs))
+	for _, dc := range cfgs {
+		exp, err := newTraceExporter(ctx, dc)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, err.Error())
+		}
+		processors = append(processors, createBatchSpanProcessor(exp, dc))
+		exps = append(exps, exp)
+	}
+
+	if len(processors) == 1 {
+		return processors[0], exps, nil
+	}
+
+	return &fanoutSpanProcessor{processors: processors}, exps, nil
+}
+
+// newMetricReaders returns one reader per metric destination, each one
+// with its own exporter, and the exporters.
+func newMetricReaders(ctx context.Context, cfg Config) ([]sdkmetric.Reader, []sdkmetric.Exporter, error) {
+	cfgs, err := metricDestinationConfigs(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	readers := make([]sdkmetric.Reader, 0, len(cfgs))
+	exps := make([]sdkmetric.Exporter, 0, len(cfgs))
+	for _, dc := range cfgs {
+		exp, err := newMetricExporter(ctx, dc)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, err.Error())
+		}
+		readers = append(readers, createMetricReader(exp, dc))
+		exps = append(exps, exp)
+	}
+
+	return readers, exps, nil
+}
+
+/*
+fanoutSpanProcessor forwards the spans to several span processors, one per
+trace destination. Each destination has its own batch span processor, with
+its own queue, so a slow or unavailable destination does not block the
+others.
+*/
+type fanoutSpanProcessor struct {
+	processors []sdktrace.SpanProcessor
+}
+
+func (p *fanoutSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	for _, sp := range p.processors {
+		sp.OnStart(parent, s)
+	}
+}
+
+func (p *fanoutSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	for _, sp := range p.processors {
+		sp.OnEnd(s)
+	}
+}
+
+// Shutdown shuts down every processor and returns the first error.
+func (p *fanoutSpanProcessor) Shutdown(ctx context.Context) error {
+	var first error
+	for _, sp := range p.processors {
+		if err := sp.Shutdown(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// ForceFlush flushes every processor and returns the first error.
+func (p *fanoutSpanProcessor) ForceFlush(ctx context.Context) error {
+	var first error
+	for _, sp := range p.processors {
+		if err := sp.ForceFlush(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
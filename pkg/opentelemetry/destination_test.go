@@ -0,0 +1,121 @@
+package otelpp
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestDestinationConfigs_Names(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  error
+	}{
+		{name: "valid", names: []string{"backup-1", "eu.collector_2"}},
+		{name: "empty", names: []string{""}, want: ErrInvalidDestinationName},
+		{name: "dot", names: []string{"."}, want: ErrInvalidDestinationName},
+		{name: "parent", names: []string{".."}, want: ErrInvalidDestinationName},
+		{name: "path", names: []string{"a/b"}, want: ErrInvalidDestinationName},
+		{name: "duplicated", names: []string{"backup", "backup"}, want: ErrInvalidDestinationName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOtelEnv(t)
+
+			var traceOpts []TraceOptionProvider
+			var metricOpts []MetricOptionProvider
+			for _, name := range tt.names {
+				traceOpts = append(traceOpts, WithTraceDestination(name, WithTraceEndpoint("backup:4317")))
+				metricOpts = append(metricOpts, WithMetricDestination(name, WithMetricEndpoint("backup:4317")))
+			}
+			cfg := buildConfig(WithTrace(traceOpts...), WithMetric(metricOpts...))
+
+			if _, err := traceDestinationConfigs(cfg); !errors.Is(err, tt.want) {
+				t.Errorf("traceDestinationConfigs: %v, want %v", err, tt.want)
+			}
+			if _, err := metricDestinationConfigs(cfg); !errors.Is(err, tt.want) {
+				t.Errorf("metricDestinationConfigs: %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDestinationConfigs_MissingEndpoint(t *testing.T) {
+	clearOtelEnv(t)
+
+	// the endpoint of the provider is not inherited
+	cfg := buildConfig(
+		WithTraceEndpoint("collector:4317"),
+		WithTrace(WithTraceDestination("backup")),
+	)
+
+	if _, err := traceDestinationConfigs(cfg); !errors.Is(err, ErrMissingDestinationEndpoint) {
+		t.Errorf("traceDestinationConfigs: %v, want ErrMissingDestinationEndpoint", err)
+	}
+}
+
+func TestDestinationConfigs_Inheritance(t *testing.T) {
+	clearOtelEnv(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS", "api-key=env")
+
+	spoolDir := t.TempDir()
+	cfg := buildConfig(
+		WithTraceEndpoint("collector:4317"),
+		WithHeaders(map[string]string{"api-key": "primary"}),
+		WithInsecure(true),
+		WithTLSServerName("collector.internal"),
+		WithProtocol(ProtocolHTTPProtobuf),
+		WithSpool(SpoolConfig{Directory: spoolDir}),
+		WithTrace(
+			WithTraceDestination("backup", WithTraceEndpoint("backup:4318")),
+			WithTraceDestination("vendor",
+				WithTraceEndpoint("vendor:4317"),
+				WithHeaders(map[string]string{"x-token": "vendor"}),
+				WithInsecure(false),
+				WithProtocol(ProtocolGRPC),
+			),
+		),
+	)
+
+	cfgs, err := traceDestinationConfigs(cfg.signalConfig(envSignalTraces))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfgs) != 3 {
+		t.Fatalf("configs = %d, want the primary and 2 destinations", len(cfgs))
+	}
+
+	primary, backup, vendor := cfgs[0], cfgs[1], cfgs[2]
+
+	if primary.Headers["api-key"] != "primary" || primary.destinationName != "" {
+		t.Errorf("primary headers = %v, name = %q", primary.Headers, primary.destinationName)
+	}
+
+	// the destinations keep the transport of the provider, not its headers
+	if backup.TraceEndpoint != "backup:4318" || backup.destinationName != "backup" {
+		t.Errorf("backup = %q %q", backup.TraceEndpoint, backup.destinationName)
+	}
+	if backup.Headers != nil {
+		t.Errorf("backup headers = %v, want none", backup.Headers)
+	}
+	if !backup.Insecure || backup.ServerName != "collector.internal" || backup.traceProtocol() != ProtocolHTTPProtobuf {
+		t.Errorf("backup insecure = %v, server name = %q, protocol = %q, want the ones of the provider",
+			backup.Insecure, backup.ServerName, backup.traceProtocol())
+	}
+	if got := backup.spool.Directory; got != filepath.Join(spoolDir, "backup") {
+		t.Errorf("backup spool = %q, want the backup subdirectory", got)
+	}
+
+	// and override them with their options
+	if vendor.Headers["x-token"] != "vendor" || len(vendor.Headers) != 1 {
+		t.Errorf("vendor headers = %v, want x-token only", vendor.Headers)
+	}
+	if vendor.Insecure || vendor.traceProtocol() != ProtocolGRPC {
+		t.Errorf("vendor insecure = %v, protocol = %q, want false and grpc", vendor.Insecure, vendor.traceProtocol())
+	}
+	if cfg.spool.Directory != spoolDir {
+		t.Errorf("provider spool = %q, changed by the destinations", cfg.spool.Directory)
+	}
+}
@@ -0,0 +1,440 @@
+package otelpp
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	envServiceName = "OTEL_SERVICE_NAME"
+
+	envOtlpPrefix           = "OTEL_EXPORTER_OTLP_"
+	envSignalTraces         = "TRACES_"
+	envSignalMetrics        = "METRICS_"
+	envSignalLogs           = "LOGS_"
+	envOtlpEndpoint         = "ENDPOINT"
+	envOtlpInsecure         = "INSECURE"
+	envOtlpHeaders          = "HEADERS"
+	envOtlpProtocol         = "PROTOCOL"
+	envOtlpCompression      = "COMPRESSION"
+	envOtlpTimeout          = "TIMEOUT"
+	envOtlpCertificate      = "CERTIFICATE"
+	envOtlpClientCert       = "CLIENT_CERTIFICATE"
+	envOtlpClientKey        = "CLIENT_KEY"
+	envBSPScheduleDelay     = "OTEL_BSP_SCHEDULE_DELAY"
+	envBSPExportTimeout     = "OTEL_BSP_EXPORT_TIMEOUT"
+	envBSPMaxQueueSize      = "OTEL_BSP_MAX_QUEUE_SIZE"
+	envBSPMaxExportBatch    = "OTEL_BSP_MAX_EXPORT_BATCH_SIZE"
+	envMetricExportInterval = "OTEL_METRIC_EXPORT_INTERVAL"
+	envMetricExportTimeout  = "OTEL_METRIC_EXPORT_TIMEOUT"
+	envMetricTemporality    = "OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE"
+)
+
+/*
+applyEnv sets cfg from the environment variables defined by the OpenTelemetry
+SDK specification. It is applied before the OptionProvider, so the options
+always take precedence. Invalid values are skipped and returned as errors.
+
+All the OTLP variables accept the per signal variants, e.g.
+OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, which take precedence over the shared
+one, e.g. OTEL_EXPORTER_OTLP_ENDPOINT, for the exporter of their signal only.
+The per signal headers, compression, timeout, insecure flag and certificates
+are kept in cfg and resolved by signalConfig when the exporter of the signal
+is created, the options setting the same fields take precedence over them.
+
+The resource attributes, OTEL_RESOURCE_ATTRIBUTES, are read by createResource.
+*/
+func applyEnv(cfg *Config) []error {
+	var errs []error
+
+	if v, ok := lookupEnv(envServiceName); ok {
+		cfg.ServiceName = v
+	}
+
+	if v, ok := lookupEnv(envPropagators); ok {
+		propagators, err := PropagatorsFromString(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", envPropagators, err))
+		} else {
+			cfg.propagators = propagators
+		}
+	}
+
+	cfg.signalEnvs = map[string]*signalEnv{
+		envSignalTraces:  {},
+		envSignalMetrics: {},
+		envSignalLogs:    {},
+	}
+
+	errs = append(errs, applyEnvEndpoints(cfg)...)
+	errs = append(errs, applyEnvProtocols(cfg)...)
+	errs = append(errs, applyEnvOtlp(cfg)...)
+	errs = append(errs, applyEnvBatch(cfg)...)
+
+	if v, ok := lookupEnv(envMetricTemporality); ok {
+		preference, err := TemporalityPreferenceFromString(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", envMetricTemporality, err))
+		} else {
+			cfg.temporality = preference.Selector()
+		}
+	}
+
+	return errs
+}
+
+// applyEnvEndpoints sets the endpoints and their URL path. The path of the
+// shared endpoint is suffixed with the signal path, e.g. /v1/traces, and the
+// one of a per signal endpoint is used as is. The http scheme makes the
+// connection of the signal insecure unless OTEL_EXPORTER_OTLP_INSECURE or
+// its per signal variant is set.
+func applyEnvEndpoints(cfg *Config) []error {
+	var errs []error
+
+	sharedInsecure := false
+	if v, ok := lookupEnv(envOtlpPrefix + envOtlpInsecure); ok {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %q", envOtlpPrefix+envOtlpInsecure, v))
+		} else {
+			cfg.Insecure = insecure
+			sharedInsecure = true
+		}
+	}
+
+	signals := []struct {
+		prefix      string
+		defaultPath string
+		endpoint    *string
+		path        *string
+	}{
+		{envSignalTraces, defaultTracesPath, &cfg.TraceEndpoint, &cfg.tracePath},
+		{envSignalMetrics, defaultMetricsPath, &cfg.MetricEndpoint, &cfg.metricPath},
+		{envSignalLogs, defaultLogsPath, &cfg.LogEndpoint, &cfg.logPath},
+	}
+
+	for _, s := range signals {
+		key := envOtlpPrefix + s.prefix + envOtlpEndpoint
+		raw, ok := lookupEnv(key)
+		signal := ok
+
+		if !ok {
+			key = envOtlpPrefix + envOtlpEndpoint
+			if raw, ok = lookupEnv(key); !ok {
+				continue
+			}
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid endpoint %s: %q", key, raw))
+			continue
+		}
+
+		*s.endpoint = u.Host
+		if signal {
+			*s.path = u.Path
+		} else {
+			*s.path = strings.TrimSuffix(u.Path, "/") + s.defaultPath
+		}
+
+		if u.Scheme == "http" && !sharedInsecure {
+			cfg.signalEnvs[s.prefix].endpointInsecure = true
+		}
+	}
+
+	return errs
+}
+
+func applyEnvProtocols(cfg *Config) []error {
+	var errs []error
+
+	protocols := []struct {
+		key      string
+		protocol *Protocol
+	}{
+		{envOtlpPrefix + envOtlpProtocol, &cfg.Protocol},
+		{envOtlpPrefix + envSignalTraces + envOtlpProtocol, &cfg.TraceProtocol},
+		{envOtlpPrefix + envSignalMetrics + envOtlpProtocol, &cfg.MetricProtocol},
+		{envOtlpPrefix + envSignalLogs + envOtlpProtocol, &cfg.LogProtocol},
+	}
+
+	for _, p := range protocols {
+		v, ok := lookupEnv(p.key)
+		if !ok {
+			continue
+		}
+
+		protocol, err := ProtocolFromString(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", p.key, err))
+			continue
+		}
+		*p.protocol = protocol
+	}
+
+	return errs
+}
+
+// signalEnv is the OTLP configuration of a signal read from the per signal
+// variables, e.g. OTEL_EXPORTER_OTLP_TRACES_HEADERS. The unset fields use the
+// shared configuration.
+type signalEnv struct {
+	headers          map[string]string
+	compression      *bool
+	timeout          *time.Duration
+	insecure         *bool
+	endpointInsecure bool
+	caCertFile       string
+	clientCertFile   string
+	clientKeyFile    string
+}
+
+// applyEnvOtlp sets the shared OTLP fields of cfg and keeps the per signal
+// ones in cfg.signalEnvs.
+func applyEnvOtlp(cfg *Config) []error {
+	var errs []error
+
+	shared := &signalEnv{}
+	errs = append(errs, lookupEnvOtlp("", shared)...)
+
+	for _, signal := range []string{envSignalTraces, envSignalMetrics, envSignalLogs} {
+		e := cfg.signalEnvs[signal]
+		errs = append(errs, lookupEnvOtlp(signal, e)...)
+
+		key := envOtlpPrefix + signal + envOtlpInsecure
+		if v, ok := lookupEnv(key); ok {
+			insecure, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %q", key, v))
+			} else {
+				e.insecure = &insecure
+			}
+		}
+	}
+
+	if shared.headers != nil {
+		cfg.Headers = shared.headers
+	}
+	if shared.compression != nil {
+		cfg.UseGzipCompression = *shared.compression
+	}
+	if shared.timeout != nil {
+		cfg.Timeout = *shared.timeout
+	}
+	if shared.caCertFile != "" {
+		cfg.CACertFile = shared.caCertFile
+	}
+	if shared.clientCertFile != "" {
+		cfg.ClientCertFile = shared.clientCertFile
+	}
+	if shared.clientKeyFile != "" {
+		cfg.ClientKeyFile = shared.clientKeyFile
+	}
+
+	return errs
+}
+
+// lookupEnvOtlp reads the OTLP variables of signal, the shared ones when
+// signal is empty, into e.
+func lookupEnvOtlp(signal string, e *signalEnv) []error {
+	var errs []error
+
+	key := envOtlpPrefix + signal + envOtlpHeaders
+	if v, ok := lookupEnv(key); ok {
+		headers, err := parseEnvHeaders(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", key, err))
+		} else {
+			e.headers = headers
+		}
+	}
+
+	key = envOtlpPrefix + signal + envOtlpCompression
+	if v, ok := lookupEnv(key); ok {
+		switch strings.ToLower(v) {
+		case "gzip":
+			gzip := true
+			e.compression = &gzip
+		case "none":
+			gzip := false
+			e.compression = &gzip
+		default:
+			errs = append(errs, fmt.Errorf("invalid %s: %q, use gzip or none", key, v))
+		}
+	}
+
+	key = envOtlpPrefix + signal + envOtlpTimeout
+	if v, ok := lookupEnv(key); ok {
+		timeout, err := parseEnvMillis(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", key, err))
+		} else {
+			e.timeout = &timeout
+		}
+	}
+
+	e.caCertFile, _ = lookupEnv(envOtlpPrefix + signal + envOtlpCertificate)
+	e.clientCertFile, _ = lookupEnv(envOtlpPrefix + signal + envOtlpClientCert)
+	e.clientKeyFile, _ = lookupEnv(envOtlpPrefix + signal + envOtlpClientKey)
+
+	return errs
+}
+
+// signalConfig returns cfg with the per signal OTLP variables of signal,
+// e.g. envSignalTraces, over the shared configuration.
+func (c Config) signalConfig(signal string) Config {
+	e, ok := c.signalEnvs[signal]
+	if !ok {
+		return c
+	}
+
+	if e.headers != nil {
+		c.Headers = e.headers
+	}
+	if e.compression != nil {
+		c.UseGzipCompression = *e.compression
+	}
+	if e.timeout != nil {
+		c.Timeout = *e.timeout
+	}
+	if e.insecure != nil {
+		c.Insecure = *e.insecure
+	} else if e.endpointInsecure {
+		c.Insecure = true
+	}
+	if e.caCertFile != "" {
+		c.CACertFile = e.caCertFile
+	}
+	if e.clientCertFile != "" {
+		c.ClientCertFile = e.clientCertFile
+	}
+	if e.clientKeyFile != "" {
+		c.ClientKeyFile = e.clientKeyFile
+	}
+
+	return c
+}
+
+// overrideSignalEnv clears a field of the per signal variables, called by
+// the options setting the shared field, which take precedence.
+func (c *Config) overrideSignalEnv(clear func(e *signalEnv)) {
+	for _, e := range c.signalEnvs {
+		clear(e)
+	}
+}
+
+// overrideEnvEndpoint clears the URL path and the insecure flag derived from
+// the endpoint variable of signal, called by the options setting the
+// endpoint of signal, which take precedence.
+func (c *Config) overrideEnvEndpoint(signal string) {
+	switch signal {
+	case envSignalTraces:
+		c.tracePath = ""
+	case envSignalMetrics:
+		c.metricPath = ""
+	case envSignalLogs:
+		c.logPath = ""
+	}
+
+	if e, ok := c.signalEnvs[signal]; ok {
+		e.endpointInsecure = false
+	}
+}
+
+func applyEnvBatch(cfg *Config) []error {
+	var errs []error
+
+	durations := []struct {
+		key   string
+		value **time.Duration
+	}{
+		{envBSPScheduleDelay, &cfg.sendIntervalTrace},
+		{envBSPExportTimeout, &cfg.exportTimeoutTrace},
+		{envMetricExportInterval, &cfg.sendIntervalMetric},
+		{envMetricExportTimeout, &cfg.exportTimeoutMetric},
+	}
+
+	for _, d := range durations {
+		v, ok := lookupEnv(d.key)
+		if !ok {
+			continue
+		}
+
+		duration, err := parseEnvMillis(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", d.key, err))
+			continue
+		}
+		*d.value = &duration
+	}
+
+	sizes := []struct {
+		key   string
+		value *int
+	}{
+		{envBSPMaxQueueSize, &cfg.maxQueueSizeTrace},
+		{envBSPMaxExportBatch, &cfg.maxExportBatchSizeTrace},
+	}
+
+	for _, s := range sizes {
+		v, ok := lookupEnv(s.key)
+		if !ok {
+			continue
+		}
+
+		size, err := strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			errs = append(errs, fmt.Errorf("invalid %s: %q, must be a positive integer", s.key, v))
+			continue
+		}
+		*s.value = size
+	}
+
+	return errs
+}
+
+// lookupEnv returns the trimmed value of key, an empty value is not set.
+func lookupEnv(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+// parseEnvHeaders parses the W3C Baggage like list of headers, e.g.
+// "api-key=secret,tenant=a%20b", where the values are URL encoded.
+func parseEnvHeaders(v string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	for _, header := range strings.Split(v, ",") {
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(header, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid header: %q", header)
+		}
+
+		value, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid header value: %q", header)
+		}
+		headers[key] = value
+	}
+
+	return headers, nil
+}
+
+// parseEnvMillis parses a duration expressed in milliseconds.
+func parseEnvMillis(v string) (time.Duration, error) {
+	ms, err := strconv.Atoi(v)
+	if err != nil || ms < 0 {
+		return 0, fmt.Errorf("%q, must be a positive number of milliseconds", v)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
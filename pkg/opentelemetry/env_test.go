@@ -0,0 +1,262 @@
+package otelpp
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+)
+
+// clearOtelEnv unsets the OTEL_* variables of the environment of the test.
+func clearOtelEnv(t *testing.T) {
+	t.Helper()
+
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); strings.HasPrefix(key, "OTEL_") {
+			t.Setenv(key, "")
+		}
+	}
+}
+
+func TestApplyEnv_Endpoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		traces     string
+		tracePath  string
+		metrics    string
+		metricPath string
+		logPath    string
+	}{
+		{
+			name:       "shared endpoint suffixed with the signal path",
+			env:        map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "https://collector:4318/otlp/"},
+			traces:     "collector:4318",
+			tracePath:  "/otlp/v1/traces",
+			metrics:    "collector:4318",
+			metricPath: "/otlp/v1/metrics",
+			logPath:    "/otlp/v1/logs",
+		},
+		{
+			name: "per signal endpoint used as is",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT":        "https://collector:4318",
+				"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "https://traces:4318/custom",
+			},
+			traces:     "traces:4318",
+			tracePath:  "/custom",
+			metrics:    "collector:4318",
+			metricPath: "/v1/metrics",
+			logPath:    "/v1/logs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOtelEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var cfg Config
+			if errs := applyEnv(&cfg); len(errs) > 0 {
+				t.Fatalf("applyEnv: %v", errs)
+			}
+
+			if cfg.TraceEndpoint != tt.traces || cfg.tracePath != tt.tracePath {
+				t.Errorf("traces = %q %q, want %q %q", cfg.TraceEndpoint, cfg.tracePath, tt.traces, tt.tracePath)
+			}
+			if cfg.MetricEndpoint != tt.metrics || cfg.metricPath != tt.metricPath {
+				t.Errorf("metrics = %q %q, want %q %q", cfg.MetricEndpoint, cfg.metricPath, tt.metrics, tt.metricPath)
+			}
+			if cfg.logPath != tt.logPath {
+				t.Errorf("log path = %q, want %q", cfg.logPath, tt.logPath)
+			}
+		})
+	}
+}
+
+func TestApplyEnv_Insecure(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		opts []OptionProvider
+		want map[string]bool
+	}{
+		{
+			name: "http scheme of a signal only",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT":        "https://collector:4317",
+				"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://localhost:4317",
+			},
+			want: map[string]bool{envSignalTraces: true, envSignalMetrics: false, envSignalLogs: false},
+		},
+		{
+			name: "http scheme of the shared endpoint",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317"},
+			want: map[string]bool{envSignalTraces: true, envSignalMetrics: true, envSignalLogs: true},
+		},
+		{
+			name: "shared insecure over the scheme",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317",
+				"OTEL_EXPORTER_OTLP_INSECURE": "false",
+			},
+			want: map[string]bool{envSignalTraces: false, envSignalMetrics: false, envSignalLogs: false},
+		},
+		{
+			name: "per signal insecure",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT":           "http://localhost:4317",
+				"OTEL_EXPORTER_OTLP_METRICS_INSECURE":   "false",
+				"OTEL_EXPORTER_OTLP_LOGS_INSECURE":      "true",
+				"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT":    "https://collector:4317",
+				"OTEL_EXPORTER_OTLP_TRACES_CERTIFICATE": "/etc/ca.pem",
+			},
+			want: map[string]bool{envSignalTraces: false, envSignalMetrics: false, envSignalLogs: true},
+		},
+		{
+			name: "endpoint option over the scheme",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317"},
+			opts: []OptionProvider{WithTraceEndpoint("collector:4317")},
+			want: map[string]bool{envSignalTraces: false, envSignalMetrics: true, envSignalLogs: true},
+		},
+		{
+			name: "insecure option over the env",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT":        "http://localhost:4317",
+				"OTEL_EXPORTER_OTLP_LOGS_INSECURE":   "true",
+				"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "https://collector:4317",
+			},
+			opts: []OptionProvider{WithInsecure(false)},
+			want: map[string]bool{envSignalTraces: false, envSignalMetrics: false, envSignalLogs: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOtelEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg := buildConfig(tt.opts...)
+
+			for signal, want := range tt.want {
+				if got := cfg.signalConfig(signal).Insecure; got != want {
+					t.Errorf("%s insecure = %v, want %v", signal, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyEnv_Otlp(t *testing.T) {
+	clearOtelEnv(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_PROTOCOL", "http/json")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret,tenant=a%20b")
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_HEADERS", "api-key=logs")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "2000")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_TIMEOUT", "500")
+	t.Setenv("OTEL_EXPORTER_OTLP_COMPRESSION", "gzip")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_COMPRESSION", "none")
+
+	var cfg Config
+	if errs := applyEnv(&cfg); len(errs) > 0 {
+		t.Fatalf("applyEnv: %v", errs)
+	}
+
+	if cfg.Protocol != ProtocolHTTPProtobuf || cfg.MetricProtocol != ProtocolHTTPJSON || cfg.TraceProtocol != "" {
+		t.Errorf("protocols = %q %q %q", cfg.Protocol, cfg.TraceProtocol, cfg.MetricProtocol)
+	}
+
+	tests := []struct {
+		signal  string
+		headers map[string]string
+		timeout time.Duration
+		gzip    bool
+	}{
+		{envSignalTraces, map[string]string{"api-key": "secret", "tenant": "a b"}, 500 * time.Millisecond, true},
+		{envSignalMetrics, map[string]string{"api-key": "secret", "tenant": "a b"}, 2 * time.Second, false},
+		{envSignalLogs, map[string]string{"api-key": "logs"}, 2 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		c := cfg.signalConfig(tt.signal)
+		if !reflect.DeepEqual(c.Headers, tt.headers) {
+			t.Errorf("%s headers = %v, want %v", tt.signal, c.Headers, tt.headers)
+		}
+		if c.Timeout != tt.timeout {
+			t.Errorf("%s timeout = %v, want %v", tt.signal, c.Timeout, tt.timeout)
+		}
+		if c.UseGzipCompression != tt.gzip {
+			t.Errorf("%s gzip = %v, want %v", tt.signal, c.UseGzipCompression, tt.gzip)
+		}
+	}
+}
+
+func TestBuildConfig_OptionsOverEnv(t *testing.T) {
+	clearOtelEnv(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "https://collector:4318/custom/traces")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_TIMEOUT", "500")
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_HEADERS", "api-key=logs")
+	t.Setenv("OTEL_SERVICE_NAME", "env")
+
+	cfg := buildConfig(
+		WithTraceEndpoint("other:4318"),
+		WithTimeout(time.Second),
+		WithHeaders(map[string]string{"api-key": "option"}),
+		WithServiceName("option"),
+	)
+
+	if cfg.TraceEndpoint != "other:4318" || signalPath(cfg.tracePath, defaultTracesPath) != defaultTracesPath {
+		t.Errorf("traces = %q %q, want other:4318 without the env path", cfg.TraceEndpoint, cfg.tracePath)
+	}
+	if got := cfg.signalConfig(envSignalMetrics).Timeout; got != time.Second {
+		t.Errorf("metrics timeout = %v, want 1s", got)
+	}
+	if got := cfg.signalConfig(envSignalLogs).Headers["api-key"]; got != "option" {
+		t.Errorf("logs api-key = %q, want option", got)
+	}
+	if cfg.ServiceName != "option" {
+		t.Errorf("service name = %q, want option", cfg.ServiceName)
+	}
+}
+
+func TestBuildConfig_InvalidEnv(t *testing.T) {
+	clearOtelEnv(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://collector:4317")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "://invalid")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "smtp")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "-5")
+	t.Setenv("OTEL_EXPORTER_OTLP_COMPRESSION", "zstd")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "no-value")
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_INSECURE", "maybe")
+	t.Setenv("OTEL_BSP_MAX_QUEUE_SIZE", "0")
+
+	var logged []string
+	logger := funcr.New(func(_, args string) {
+		logged = append(logged, args)
+	}, funcr.Options{})
+
+	cfg := buildConfig(WithLogger(logger))
+
+	if len(logged) != 7 {
+		t.Errorf("logged %d errors, want 7: %v", len(logged), logged)
+	}
+
+	// the invalid values are ignored
+	if cfg.TraceEndpoint != "" || cfg.MetricEndpoint != "collector:4317" {
+		t.Errorf("endpoints = %q %q, want the invalid traces endpoint ignored", cfg.TraceEndpoint, cfg.MetricEndpoint)
+	}
+	if cfg.Protocol != "" || cfg.Timeout != 0 || cfg.UseGzipCompression || cfg.Headers != nil || cfg.maxQueueSizeTrace != 0 {
+		t.Errorf("config = %+v, want the invalid values ignored", cfg.OtlpConfig)
+	}
+	if cfg.signalConfig(envSignalLogs).Insecure {
+		t.Error("logs insecure, want the invalid value ignored")
+	}
+}
@@ -0,0 +1,386 @@
+package otelpp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+const (
+	defaultFailoverFailureThreshold = 3
+	defaultFailoverOpenDuration     = 30 * time.Second
+	defaultFailoverSecondaryTimeout = 10 * time.Second
+
+	metricFailoverState = "otelpp.failover.state"
+)
+
+var ErrMissingFailoverEndpoint = errors.New("missing endpoint of the failover")
+
+// Compile-time check the failover wrappers implement the SDK interfaces.
+var (
+	_ otlptrace.Client   = (*failoverTraceClient)(nil)
+	_ sdkmetric.Exporter = (*failoverMetricExporter)(nil)
+)
+
+/*
+FailoverConfig - configuration of the circuit breaker of the primary endpoint, every export failed on the
+primary endpoint is sent to the secondary endpoint, also before the circuit opens, within the timeout of
+the secondary endpoint, the one of the provider unless WithTimeout is set in its options, default value 10s,
+so that an export failed by timing out on the primary endpoint is not lost
+FailureThreshold - consecutive failed exports to the primary endpoint opening the circuit, default value 3
+OpenDuration - time the exports go to the secondary endpoint before the primary endpoint is tried again, default value 30s
+*/
+type FailoverConfig struct {
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+func (c FailoverConfig) withDefaults() FailoverConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = defaultFailoverFailureThreshold
+	}
+	if c.OpenDuration <= 0 {
+		c.OpenDuration = defaultFailoverOpenDuration
+	}
+	return c
+}
+
+// failover is the secondary endpoint of a signal, its configuration is built
+// like the configuration of a destination.
+type failover struct {
+	cfg  FailoverConfig
+	opts []OptionProvider
+}
+
+// config returns the Config of the secondary endpoint. The spool of the
+// provider is not used, it stores the exports failed on both endpoints.
+func (f failover) config(cfg Config) Config {
+	sc := endpointConfig(cfg, f.opts)
+	sc.spool = nil
+	return sc
+}
+
+type circuitState int
+
+// The states of the circuit breaker, exported by the otelpp.failover.state
+// gauge.
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("UNKNOWN[%d]", int(s))
+	}
+}
+
+/*
+circuitBreaker selects the endpoint of the exports:
+
+  - closed: the exports go to the primary endpoint, and to the secondary
+    endpoint when they fail, the circuit opens after FailureThreshold
+    consecutive failures
+  - open: the exports go to the secondary endpoint, the circuit becomes
+    half-open after OpenDuration
+  - half-open: one export tries the primary endpoint, while the others go
+    to the secondary endpoint, the circuit closes when it succeeds, failing
+    back to the primary endpoint, and opens again when it fails
+*/
+type circuitBreaker struct {
+	signal      string
+	destination string
+	threshold   int
+	openFor     time.Duration
+	timeout     time.Duration
+	logger      logr.Logger
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+
+	now func() time.Time
+}
+
+// newCircuitBreaker returns the circuit breaker of the primary endpoint of
+// cfg, failing over to the secondary endpoint of sc.
+func newCircuitBreaker(cfg Config, sc Config, signal string, fc FailoverConfig) *circuitBreaker {
+	fc = fc.withDefaults()
+
+	timeout := defaultFailoverSecondaryTimeout
+	if sc.ValidTimeout() {
+		timeout = sc.Timeout
+	}
+
+	return &circuitBreaker{
+		signal:      signal,
+		destination: cfg.destinationName,
+		threshold:   fc.FailureThreshold,
+		openFor:     fc.OpenDuration,
+		timeout:     timeout,
+		logger:      cfg.Logger,
+		now:         time.Now,
+	}
+}
+
+// do exports with primary while the circuit allows it, and with secondary
+// otherwise. An export failed on primary is sent to secondary, also while
+// the failures are below the threshold, so it is not lost. secondary then
+// runs within its own timeout, ctx may have expired on primary.
+func (b *circuitBreaker) do(ctx context.Context, primary, secondary func(ctx context.Context) error) error {
+	if !b.allow() {
+		return secondary(ctx)
+	}
+
+	err := primary(ctx)
+	if err == nil {
+		b.success()
+		return nil
+	}
+
+	b.failure(err)
+
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, b.timeout)
+	defer cancel()
+
+	return secondary(ctx)
+}
+
+// detachedContext keeps the values of its parent context, without its
+// deadline and cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// allow reports whether the export goes to the primary endpoint.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitClosed:
+		return true
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.openFor {
+			return false
+		}
+		b.setState(circuitHalfOpen, nil)
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.state != circuitClosed {
+		b.setState(circuitClosed, nil)
+	}
+}
+
+// failure records the failed export to the primary endpoint, opening the
+// circuit at the threshold or when the half-open export fails.
+func (b *circuitBreaker) failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitClosed:
+		b.failures++
+		if b.failures < b.threshold {
+			return
+		}
+	case circuitOpen:
+		return
+	}
+
+	b.failures = 0
+	b.openedAt = b.now()
+	b.setState(circuitOpen, err)
+}
+
+func (b *circuitBreaker) setState(state circuitState, cause error) {
+	from := b.state
+	b.state = state
+
+	kvs := []interface{}{"signal", b.signal, "from", from.String(), "to", state.String()}
+	if b.destination != "" {
+		kvs = append(kvs, "destination", b.destination)
+	}
+	if cause != nil {
+		kvs = append(kvs, "error", cause.Error())
+	}
+
+	b.logger.Info("failover circuit breaker state changed", kvs...)
+}
+
+func (b *circuitBreaker) currentState() circuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// RegisterMetrics exports the state of the circuit breaker through m.
+func (b *circuitBreaker) RegisterMetrics(m Meter) error {
+	state, err := m.Int64ObservableGauge(metricFailoverState,
+		instrument.WithDescription("state of the failover circuit breaker of the primary endpoint: 0 closed, 1 open, 2 half-open"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("signal", b.signal)}
+	if b.destination != "" {
+		attrs = append(attrs, attribute.String("destination", b.destination))
+	}
+
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(state, int64(b.currentState()), attrs...)
+		return nil
+	}, state)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	return nil
+}
+
+// failoverTraceClient uploads the spans to the secondary client while the
+// circuit of the primary client is open.
+type failoverTraceClient struct {
+	primary   otlptrace.Client
+	secondary otlptrace.Client
+	*circuitBreaker
+}
+
+func newFailoverTraceClient(ctx context.Context, primary otlptrace.Client, cfg Config) (otlptrace.Client, error) {
+	sc := cfg.traceFailover.config(cfg)
+	if sc.TraceEndpoint == "" {
+		return nil, fmt.Errorf("%w: traces", ErrMissingFailoverEndpoint)
+	}
+
+	secondary, err := newProtocolTraceClient(ctx, sc)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	return &failoverTraceClient{
+		primary:        primary,
+		secondary:      secondary,
+		circuitBreaker: newCircuitBreaker(cfg, sc, spoolSignalTraces, cfg.traceFailover.cfg),
+	}, nil
+}
+
+func (c *failoverTraceClient) Start(ctx context.Context) error {
+	if err := c.primary.Start(ctx); err != nil {
+		return err
+	}
+
+	return c.secondary.Start(ctx)
+}
+
+func (c *failoverTraceClient) Stop(ctx context.Context) error {
+	err := c.primary.Stop(ctx)
+	if serr := c.secondary.Stop(ctx); err == nil {
+		err = serr
+	}
+
+	return err
+}
+
+func (c *failoverTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	return c.do(ctx,
+		func(ctx context.Context) error { return c.primary.UploadTraces(ctx, protoSpans) },
+		func(ctx context.Context) error { return c.secondary.UploadTraces(ctx, protoSpans) })
+}
+
+// failoverMetricExporter exports the metrics with the secondary exporter
+// while the circuit of the primary exporter is open. The temporality and
+// aggregation of the primary exporter are used for both.
+type failoverMetricExporter struct {
+	primary   sdkmetric.Exporter
+	secondary sdkmetric.Exporter
+	*circuitBreaker
+}
+
+func newFailoverMetricExporter(ctx context.Context, primary sdkmetric.Exporter, cfg Config) (sdkmetric.Exporter, error) {
+	sc := cfg.metricFailover.config(cfg)
+	if sc.MetricEndpoint == "" {
+		return nil, fmt.Errorf("%w: metrics", ErrMissingFailoverEndpoint)
+	}
+
+	secondary, err := newProtocolMetricExporter(ctx, sc)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	return &failoverMetricExporter{
+		primary:        primary,
+		secondary:      secondary,
+		circuitBreaker: newCircuitBreaker(cfg, sc, spoolSignalMetrics, cfg.metricFailover.cfg),
+	}, nil
+}
+
+func (e *failoverMetricExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return e.primary.Temporality(k)
+}
+
+func (e *failoverMetricExporter) Aggregation(k sdkmetric.InstrumentKind) aggregation.Aggregation {
+	return e.primary.Aggregation(k)
+}
+
+func (e *failoverMetricExporter) Export(ctx context.Context, rm metricdata.ResourceMetrics) error {
+	return e.do(ctx,
+		func(ctx context.Context) error { return e.primary.Export(ctx, rm) },
+		func(ctx context.Context) error { return e.secondary.Export(ctx, rm) })
+}
+
+func (e *failoverMetricExporter) ForceFlush(ctx context.Context) error {
+	err := e.primary.ForceFlush(ctx)
+	if serr := e.secondary.ForceFlush(ctx); err == nil {
+		err = serr
+	}
+
+	return err
+}
+
+func (e *failoverMetricExporter) Shutdown(ctx context.Context) error {
+	err := e.primary.Shutdown(ctx)
+	if serr := e.secondary.Shutdown(ctx); err == nil {
+		err = serr
+	}
+
+	return err
+}
@@ -0,0 +1,163 @@
+package otelpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func TestCircuitBreaker_States(t *testing.T) {
+	errPrimary := errors.New("primary down")
+
+	type step struct {
+		advance       time.Duration
+		primaryErr    error
+		wantPrimary   bool
+		wantSecondary bool
+		wantState     circuitState
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "closed below the threshold",
+			steps: []step{
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true, wantState: circuitClosed},
+				{wantPrimary: true, wantState: circuitClosed},
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true, wantState: circuitClosed},
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true, wantState: circuitClosed},
+			},
+		},
+		{
+			name: "open at the threshold and closed after the half-open success",
+			steps: []step{
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true, wantState: circuitClosed},
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true, wantState: circuitClosed},
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true, wantState: circuitOpen},
+				{advance: 29 * time.Second, wantSecondary: true, wantState: circuitOpen},
+				{advance: time.Second, wantPrimary: true, wantState: circuitClosed},
+				{wantPrimary: true, wantState: circuitClosed},
+			},
+		},
+		{
+			name: "open again after the half-open failure",
+			steps: []step{
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true},
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true},
+				{primaryErr: errPrimary, wantPrimary: true, wantSecondary: true, wantState: circuitOpen},
+				{advance: 30 * time.Second, primaryErr: errPrimary, wantPrimary: true, wantSecondary: true, wantState: circuitOpen},
+				{advance: 29 * time.Second, wantSecondary: true, wantState: circuitOpen},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now := newTestCircuitBreaker()
+
+			for i, s := range tt.steps {
+				*now = now.Add(s.advance)
+
+				var primary, secondary bool
+				err := b.do(context.Background(),
+					func(context.Context) error { primary = true; return s.primaryErr },
+					func(context.Context) error { secondary = true; return nil })
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+
+				if primary != s.wantPrimary || secondary != s.wantSecondary {
+					t.Errorf("step %d: primary %v, secondary %v, want %v, %v", i, primary, secondary, s.wantPrimary, s.wantSecondary)
+				}
+				if got := b.currentState(); got != s.wantState {
+					t.Errorf("step %d: state %s, want %s", i, got, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_HalfOpenSingleProbe(t *testing.T) {
+	b, now := newTestCircuitBreaker()
+	b.threshold = 1
+
+	b.failure(errors.New("primary down"))
+	*now = now.Add(30 * time.Second)
+
+	// the first export probes the primary endpoint, the concurrent ones go
+	// to the secondary endpoint until the probe ends
+	if !b.allow() {
+		t.Fatal("the first export after the open duration does not probe the primary endpoint")
+	}
+	if b.allow() || b.allow() {
+		t.Error("a second export probes the primary endpoint while half-open")
+	}
+	if got := b.currentState(); got != circuitHalfOpen {
+		t.Errorf("state %s, want half-open", got)
+	}
+
+	b.success()
+	if !b.allow() {
+		t.Error("the exports do not go to the primary endpoint once closed")
+	}
+}
+
+func TestCircuitBreaker_SecondaryTimeout(t *testing.T) {
+	b, _ := newTestCircuitBreaker()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	err := b.do(ctx,
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			if ctx.Err() != nil || !ok || time.Until(deadline) < 4*time.Second {
+				return errors.New("the secondary export has not its own timeout")
+			}
+			return nil
+		})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func newTestCircuitBreaker() (*circuitBreaker, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+
+	sc := Config{}
+	sc.Timeout = 5 * time.Second
+
+	b := newCircuitBreaker(Config{Logger: logr.Discard()}, sc, spoolSignalTraces, FailoverConfig{})
+	b.now = func() time.Time { return now }
+
+	return b, &now
+}
+
+func TestFailoverTraceClient_UploadTraces(t *testing.T) {
+	b, _ := newTestCircuitBreaker()
+	primary := &fakeTraceClient{errs: []error{errors.New("primary down")}}
+	secondary := &fakeTraceClient{}
+	c := &failoverTraceClient{primary: primary, secondary: secondary, circuitBreaker: b}
+
+	for _, name := range []string{"failed over", "primary"} {
+		if err := c.UploadTraces(context.Background(), testResourceSpans(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := primary.spanNames(); len(got) != 1 || got[0] != "primary" {
+		t.Errorf("primary received %v, want [primary]", got)
+	}
+	if got := secondary.spanNames(); len(got) != 1 || got[0] != "failed over" {
+		t.Errorf("secondary received %v, want [failed over]", got)
+	}
+}
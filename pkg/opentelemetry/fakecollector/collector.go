@@ -0,0 +1,411 @@
+/*
+Package fakecollector provides an in-process OTLP receiver, used to test the
+otelpp providers end to end and to inspect the telemetry of a service without
+the docker-compose stack.
+
+The Collector receives OTLP over gRPC and over HTTP, encoded as protobuf or
+JSON, stores the received resource spans, metrics and logs, and can inject
+failures into the next responses:
+
+	c, err := fakecollector.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop(context.Background())
+
+	c.FailNext(2, fakecollector.Unavailable())
+
+	tracing, _, err := otelpp.NewProvider(ctx,
+		otelpp.WithTraceEndpoint(c.GRPCEndpoint()),
+		otelpp.WithInsecure(true),
+		...)
+
+	err = c.WaitFor(ctx, func(c *fakecollector.Collector) bool {
+		_, ok := c.FindSpan("GET /users")
+		return ok
+	})
+*/
+package fakecollector
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"otlp-stack/pkg/opentelemetry/semconv"
+)
+
+const (
+	defaultAddress = "127.0.0.1:0"
+	waitInterval   = 10 * time.Millisecond
+)
+
+// Signal is a kind of telemetry received by the Collector.
+type Signal string
+
+const (
+	SignalTraces  Signal = "traces"
+	SignalMetrics Signal = "metrics"
+	SignalLogs    Signal = "logs"
+)
+
+// ReceiveFunc is called with the accepted export requests of signal, an
+// ExportTraceServiceRequest, ExportMetricsServiceRequest or
+// ExportLogsServiceRequest.
+type ReceiveFunc func(signal Signal, req proto.Message)
+
+/*
+Config - configuration of the Collector
+GRPCAddress - address of the OTLP gRPC receiver, default value 127.0.0.1:0, a random port
+HTTPAddress - address of the OTLP HTTP receiver, default value 127.0.0.1:0, a random port
+OnReceive - called with each accepted export request, not set by default
+DisableStore - the received telemetry is not stored, only passed to OnReceive, for long running receivers
+*/
+type Config struct {
+	GRPCAddress  string
+	HTTPAddress  string
+	OnReceive    ReceiveFunc
+	DisableStore bool
+}
+
+type Option func(c *Config)
+
+// WithGRPCAddress - address of the OTLP gRPC receiver, e.g. 127.0.0.1:4317
+func WithGRPCAddress(addr string) Option {
+	return func(c *Config) {
+		c.GRPCAddress = addr
+	}
+}
+
+// WithHTTPAddress - address of the OTLP HTTP receiver, e.g. 127.0.0.1:4318
+func WithHTTPAddress(addr string) Option {
+	return func(c *Config) {
+		c.HTTPAddress = addr
+	}
+}
+
+// WithOnReceive - call f with each accepted export request
+func WithOnReceive(f ReceiveFunc) Option {
+	return func(c *Config) {
+		c.OnReceive = f
+	}
+}
+
+// WithoutStore - do not store the received telemetry, the query helpers return nothing
+func WithoutStore() Option {
+	return func(c *Config) {
+		c.DisableStore = true
+	}
+}
+
+// Collector is an in-process OTLP receiver storing the received telemetry.
+type Collector struct {
+	grpcListener net.Listener
+	httpListener net.Listener
+	grpcServer   *grpc.Server
+	httpServer   *http.Server
+	onReceive    ReceiveFunc
+	store        bool
+
+	mu              sync.Mutex
+	resourceSpans   []*tracepb.ResourceSpans
+	resourceMetrics []*metricpb.ResourceMetrics
+	resourceLogs    []*logspb.ResourceLogs
+	requests        map[Signal]int
+	failures        failures
+}
+
+// Start starts the gRPC and HTTP receivers of the Collector.
+func Start(opts ...Option) (*Collector, error) {
+	cfg := Config{
+		GRPCAddress: defaultAddress,
+		HTTPAddress: defaultAddress,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	grpcListener, err := net.Listen("tcp", cfg.GRPCAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	httpListener, err := net.Listen("tcp", cfg.HTTPAddress)
+	if err != nil {
+		_ = grpcListener.Close()
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	c := &Collector{
+		grpcListener: grpcListener,
+		httpListener: httpListener,
+		onReceive:    cfg.OnReceive,
+		store:        !cfg.DisableStore,
+		requests:     make(map[Signal]int),
+	}
+
+	c.grpcServer = grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(c.grpcServer, &traceService{c: c})
+	colmetricpb.RegisterMetricsServiceServer(c.grpcServer, &metricsService{c: c})
+	collogspb.RegisterLogsServiceServer(c.grpcServer, &logsService{c: c})
+
+	c.httpServer = &http.Server{
+		Handler:           c.httpHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() { _ = c.grpcServer.Serve(grpcListener) }()
+	go func() { _ = c.httpServer.Serve(httpListener) }()
+
+	return c, nil
+}
+
+// GRPCEndpoint returns the host:port of the OTLP gRPC receiver.
+func (c *Collector) GRPCEndpoint() string {
+	return c.grpcListener.Addr().String()
+}
+
+// HTTPEndpoint returns the host:port of the OTLP HTTP receiver.
+func (c *Collector) HTTPEndpoint() string {
+	return c.httpListener.Addr().String()
+}
+
+// Stop stops the receivers, waiting for the requests in progress until ctx
+// is done.
+func (c *Collector) Stop(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		c.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		c.grpcServer.Stop()
+	}
+
+	return c.httpServer.Shutdown(ctx)
+}
+
+// receive records a request of signal and returns the failure to respond
+// with, if any.
+func (c *Collector) receive(signal Signal) (Failure, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests[signal]++
+
+	return c.failures.next()
+}
+
+// accept stores the accepted export request of signal and passes it to the
+// OnReceive func.
+func (c *Collector) accept(signal Signal, req proto.Message) {
+	if c.store {
+		c.mu.Lock()
+		switch r := req.(type) {
+		case *coltracepb.ExportTraceServiceRequest:
+			c.resourceSpans = append(c.resourceSpans, r.ResourceSpans...)
+		case *colmetricpb.ExportMetricsServiceRequest:
+			c.resourceMetrics = append(c.resourceMetrics, r.ResourceMetrics...)
+		case *collogspb.ExportLogsServiceRequest:
+			c.resourceLogs = append(c.resourceLogs, r.ResourceLogs...)
+		}
+		c.mu.Unlock()
+	}
+
+	if c.onReceive != nil {
+		c.onReceive(signal, req)
+	}
+}
+
+// Requests returns the number of export requests of signal received,
+// including the failed ones.
+func (c *Collector) Requests(signal Signal) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.requests[signal]
+}
+
+// Reset drops the stored telemetry, the request counts and the pending
+// failures.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resourceSpans = nil
+	c.resourceMetrics = nil
+	c.resourceLogs = nil
+	c.requests = make(map[Signal]int)
+	c.failures = failures{}
+}
+
+// ResourceSpans returns the received resource spans, in the order received.
+func (c *Collector) ResourceSpans() []*tracepb.ResourceSpans {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*tracepb.ResourceSpans(nil), c.resourceSpans...)
+}
+
+// ResourceMetrics returns the received resource metrics, in the order
+// received.
+func (c *Collector) ResourceMetrics() []*metricpb.ResourceMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*metricpb.ResourceMetrics(nil), c.resourceMetrics...)
+}
+
+// ResourceLogs returns the received resource logs, in the order received.
+func (c *Collector) ResourceLogs() []*logspb.ResourceLogs {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*logspb.ResourceLogs(nil), c.resourceLogs...)
+}
+
+// Span is a received span with its resource and instrumentation scope.
+type Span struct {
+	*tracepb.Span
+	Resource *resourcepb.Resource
+	Scope    *commonpb.InstrumentationScope
+}
+
+// Metric is a received metric with its resource and instrumentation scope.
+type Metric struct {
+	*metricpb.Metric
+	Resource *resourcepb.Resource
+	Scope    *commonpb.InstrumentationScope
+}
+
+// LogRecord is a received log record with its resource and instrumentation
+// scope.
+type LogRecord struct {
+	*logspb.LogRecord
+	Resource *resourcepb.Resource
+	Scope    *commonpb.InstrumentationScope
+}
+
+// Spans returns the received spans, in the order received.
+func (c *Collector) Spans() []Span {
+	var spans []Span
+	for _, rs := range c.ResourceSpans() {
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				spans = append(spans, Span{Span: s, Resource: rs.Resource, Scope: ss.Scope})
+			}
+		}
+	}
+
+	return spans
+}
+
+// Metrics returns the received metrics, in the order received. A metric
+// exported several times is returned once per export.
+func (c *Collector) Metrics() []Metric {
+	var metrics []Metric
+	for _, rm := range c.ResourceMetrics() {
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				metrics = append(metrics, Metric{Metric: m, Resource: rm.Resource, Scope: sm.Scope})
+			}
+		}
+	}
+
+	return metrics
+}
+
+// LogRecords returns the received log records, in the order received.
+func (c *Collector) LogRecords() []LogRecord {
+	var records []LogRecord
+	for _, rl := range c.ResourceLogs() {
+		for _, sl := range rl.ScopeLogs {
+			for _, r := range sl.LogRecords {
+				records = append(records, LogRecord{LogRecord: r, Resource: rl.Resource, Scope: sl.Scope})
+			}
+		}
+	}
+
+	return records
+}
+
+// FindSpan returns the first received span named name.
+func (c *Collector) FindSpan(name string) (Span, bool) {
+	for _, s := range c.Spans() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+
+	return Span{}, false
+}
+
+// FindMetric returns the last received metric named name, which has the
+// latest values of a cumulative metric.
+func (c *Collector) FindMetric(name string) (Metric, bool) {
+	metrics := c.Metrics()
+	for i := len(metrics) - 1; i >= 0; i-- {
+		if metrics[i].Name == name {
+			return metrics[i], true
+		}
+	}
+
+	return Metric{}, false
+}
+
+// SpansOfService returns the received spans of the resources with the
+// service.name attribute equal to service.
+func (c *Collector) SpansOfService(service string) []Span {
+	var spans []Span
+	for _, s := range c.Spans() {
+		if ServiceName(s.Resource) == service {
+			spans = append(spans, s)
+		}
+	}
+
+	return spans
+}
+
+// WaitFor calls cond until it returns true, returning the error of ctx when
+// ctx is done before.
+func (c *Collector) WaitFor(ctx context.Context, cond func(c *Collector) bool) error {
+	ticker := time.NewTicker(waitInterval)
+	defer ticker.Stop()
+
+	for !cond(c) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// ServiceName returns the service.name attribute of res.
+func ServiceName(res *resourcepb.Resource) string {
+	for _, kv := range res.GetAttributes() {
+		if kv.Key == string(semconv.ServiceNameKey) {
+			return kv.GetValue().GetStringValue()
+		}
+	}
+
+	return ""
+}
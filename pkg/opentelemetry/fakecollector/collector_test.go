@@ -0,0 +1,204 @@
+package fakecollector_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelpp "otlp-stack/pkg/opentelemetry"
+	"otlp-stack/pkg/opentelemetry/fakecollector"
+)
+
+const serviceName = "fakecollector-test"
+
+func startCollector(t *testing.T) *fakecollector.Collector {
+	t.Helper()
+
+	c, err := fakecollector.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Stop(context.Background()) })
+
+	return c
+}
+
+// endpoint returns the receiver of c used by protocol.
+func endpoint(c *fakecollector.Collector, protocol otelpp.Protocol) string {
+	if protocol == otelpp.ProtocolGRPC {
+		return c.GRPCEndpoint()
+	}
+	return c.HTTPEndpoint()
+}
+
+// export creates a provider exporting to c with protocol, records a span and
+// a counter, and shuts the provider down, which exports them. It returns the
+// error of the shutdown, the failed export of the metrics.
+func export(t *testing.T, c *fakecollector.Collector, protocol otelpp.Protocol, opts ...otelpp.OptionProvider) error {
+	t.Helper()
+
+	ctx := context.Background()
+	opts = append([]otelpp.OptionProvider{
+		otelpp.WithAppEnv(otelpp.DEV),
+		otelpp.WithServiceName(serviceName),
+		otelpp.WithTraceEndpoint(endpoint(c, protocol)),
+		otelpp.WithMetricEndpoint(endpoint(c, protocol)),
+		otelpp.WithProtocol(protocol),
+		otelpp.WithInsecure(true),
+		otelpp.WithRetry(
+			otelpp.WithRetryEnable(true),
+			otelpp.WithRetryInitialInterval(10*time.Millisecond),
+			otelpp.WithRetryMaxInterval(50*time.Millisecond),
+			otelpp.WithRetryMaxElapsedTime(10*time.Second),
+		),
+	}, opts...)
+
+	tracing, meter, err := otelpp.NewProvider(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := tracing.Start(ctx, "GET /users")
+	span.SetAttributes(attribute.String("http.route", "/users"))
+	span.End()
+
+	counter, err := meter.Int64Counter("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Add(ctx, 3, attribute.String("http.route", "/users"))
+
+	if err = tracing.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	return meter.Shutdown(ctx)
+}
+
+// assertReceived checks c received the span and the counter of export.
+func assertReceived(t *testing.T, c *fakecollector.Collector) {
+	t.Helper()
+
+	s, ok := c.FindSpan("GET /users")
+	if !ok {
+		t.Fatalf("span not received, received spans: %d", len(c.Spans()))
+	}
+	if got := fakecollector.ServiceName(s.Resource); got != serviceName {
+		t.Errorf("service.name of the span = %q, want %q", got, serviceName)
+	}
+	if len(c.SpansOfService(serviceName)) != 1 {
+		t.Errorf("spans of %s = %d, want 1", serviceName, len(c.SpansOfService(serviceName)))
+	}
+
+	m, ok := c.FindMetric("requests")
+	if !ok {
+		t.Fatalf("counter not received, received metrics: %d", len(c.Metrics()))
+	}
+	dps := m.GetSum().GetDataPoints()
+	if len(dps) != 1 || dps[0].GetAsInt() != 3 {
+		t.Errorf("counter data points = %v, want one of value 3", dps)
+	}
+}
+
+var protocols = []otelpp.Protocol{
+	otelpp.ProtocolGRPC,
+	otelpp.ProtocolHTTPProtobuf,
+	otelpp.ProtocolHTTPJSON,
+}
+
+func TestCollector_ReceivesProviderExports(t *testing.T) {
+	for _, protocol := range protocols {
+		t.Run(string(protocol), func(t *testing.T) {
+			c := startCollector(t)
+
+			if err := export(t, c, protocol); err != nil {
+				t.Fatal(err)
+			}
+
+			assertReceived(t, c)
+			if got := c.Requests(fakecollector.SignalTraces); got != 1 {
+				t.Errorf("trace requests = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestCollector_Unavailable(t *testing.T) {
+	for _, protocol := range protocols {
+		t.Run(string(protocol), func(t *testing.T) {
+			c := startCollector(t)
+			c.FailNext(2, fakecollector.Unavailable())
+
+			if err := export(t, c, protocol); err != nil {
+				t.Fatal(err)
+			}
+
+			// the two failed requests of any signal are retried
+			assertReceived(t, c)
+			if got := c.Requests(fakecollector.SignalTraces) + c.Requests(fakecollector.SignalMetrics); got != 4 {
+				t.Errorf("requests = %d, want the 2 accepted and the 2 failed", got)
+			}
+		})
+	}
+}
+
+func TestCollector_TooManyRequests(t *testing.T) {
+	for _, protocol := range protocols {
+		t.Run(string(protocol), func(t *testing.T) {
+			c := startCollector(t)
+			c.FailNext(1, fakecollector.TooManyRequests(time.Second))
+
+			start := time.Now()
+			if err := export(t, c, protocol); err != nil {
+				t.Fatal(err)
+			}
+
+			assertReceived(t, c)
+
+			// the SDK exporters of http/protobuf read Retry-After as
+			// nanoseconds in this version, so they retry immediately
+			if protocol == otelpp.ProtocolHTTPProtobuf {
+				return
+			}
+			if elapsed := time.Since(start); elapsed < time.Second {
+				t.Errorf("exported after %v, before the throttling delay of 1s", elapsed)
+			}
+		})
+	}
+}
+
+func TestCollector_Slow(t *testing.T) {
+	for _, protocol := range protocols {
+		t.Run(string(protocol)+"/below timeout", func(t *testing.T) {
+			c := startCollector(t)
+			c.FailNext(1, fakecollector.Slow(200*time.Millisecond))
+
+			start := time.Now()
+			if err := export(t, c, protocol, otelpp.WithTimeout(5*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+
+			assertReceived(t, c)
+			if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+				t.Errorf("exported after %v, before the delay of 200ms", elapsed)
+			}
+		})
+
+		t.Run(string(protocol)+"/above timeout", func(t *testing.T) {
+			c := startCollector(t)
+			c.FailNext(0, fakecollector.Slow(time.Minute))
+
+			if err := export(t, c, protocol, otelpp.WithTimeout(200*time.Millisecond)); err == nil {
+				t.Error("export of the metrics succeeded after the export timeout")
+			}
+
+			if _, ok := c.FindSpan("GET /users"); ok {
+				t.Error("span received after the export timeout")
+			}
+			if c.Requests(fakecollector.SignalTraces) == 0 {
+				t.Error("no trace request received")
+			}
+		})
+	}
+}
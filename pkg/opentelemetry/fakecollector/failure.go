@@ -0,0 +1,140 @@
+package fakecollector
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type failureKind int
+
+const (
+	failureUnavailable failureKind = iota + 1
+	failureTooManyRequests
+	failureSlow
+)
+
+// Failure is the response of the Collector to a request, set with FailNext.
+type Failure struct {
+	kind       failureKind
+	retryAfter time.Duration
+	delay      time.Duration
+}
+
+// Unavailable responds with the gRPC UNAVAILABLE code or the HTTP 503
+// status, retried by the exporters.
+func Unavailable() Failure {
+	return Failure{kind: failureUnavailable}
+}
+
+// TooManyRequests responds with the gRPC RESOURCE_EXHAUSTED code and a
+// RetryInfo detail, or the HTTP 429 status and a Retry-After header, asking
+// the exporters to retry after retryAfter.
+func TooManyRequests(retryAfter time.Duration) Failure {
+	return Failure{kind: failureTooManyRequests, retryAfter: retryAfter}
+}
+
+// Slow accepts the request after delay, or fails with the error of the
+// request context when it is done before, e.g. on the export timeout.
+func Slow(delay time.Duration) Failure {
+	return Failure{kind: failureSlow, delay: delay}
+}
+
+// FailNext responds to the next n requests of any signal with f, or to all
+// the requests until ClearFailures when n is 0.
+func (c *Collector) FailNext(n int, f Failure) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = failures{failure: f, remaining: n, always: n <= 0}
+}
+
+// ClearFailures accepts the next requests.
+func (c *Collector) ClearFailures() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = failures{}
+}
+
+type failures struct {
+	failure   Failure
+	remaining int
+	always    bool
+}
+
+func (f *failures) next() (Failure, bool) {
+	if f.always {
+		return f.failure, true
+	}
+
+	if f.remaining <= 0 {
+		return Failure{}, false
+	}
+
+	f.remaining--
+
+	return f.failure, true
+}
+
+// grpcError applies f to a gRPC request, returning the status error of the
+// response, nil when the request is accepted.
+func (f Failure) grpcError(ctx context.Context) error {
+	switch f.kind {
+	case failureUnavailable:
+		return status.Error(codes.Unavailable, "fake collector unavailable")
+	case failureTooManyRequests:
+		st, err := status.New(codes.ResourceExhausted, "fake collector throttling").
+			WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(f.retryAfter)})
+		if err != nil {
+			return status.Error(codes.ResourceExhausted, "fake collector throttling")
+		}
+		return st.Err()
+	case failureSlow:
+		if err := sleep(ctx, f.delay); err != nil {
+			return status.FromContextError(err).Err()
+		}
+	}
+
+	return nil
+}
+
+// writeHTTP applies f to an HTTP request, writing the response and returning
+// true when the request is not accepted.
+func (f Failure) writeHTTP(w http.ResponseWriter, r *http.Request) bool {
+	switch f.kind {
+	case failureUnavailable:
+		http.Error(w, "fake collector unavailable", http.StatusServiceUnavailable)
+		return true
+	case failureTooManyRequests:
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(f.retryAfter.Seconds()))))
+		http.Error(w, "fake collector throttling", http.StatusTooManyRequests)
+		return true
+	case failureSlow:
+		if err := sleep(r.Context(), f.delay); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return true
+		}
+	}
+
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,64 @@
+package fakecollector
+
+import (
+	"context"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+
+	// registers the gzip compressor used by the exporters with
+	// WithGzipCompression
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+type traceService struct {
+	coltracepb.UnimplementedTraceServiceServer
+	c *Collector
+}
+
+func (s *traceService) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	if f, ok := s.c.receive(SignalTraces); ok {
+		if err := f.grpcError(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.c.accept(SignalTraces, req)
+
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+type metricsService struct {
+	colmetricpb.UnimplementedMetricsServiceServer
+	c *Collector
+}
+
+func (s *metricsService) Export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) (*colmetricpb.ExportMetricsServiceResponse, error) {
+	if f, ok := s.c.receive(SignalMetrics); ok {
+		if err := f.grpcError(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.c.accept(SignalMetrics, req)
+
+	return &colmetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+type logsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	c *Collector
+}
+
+func (s *logsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	if f, ok := s.c.receive(SignalLogs); ok {
+		if err := f.grpcError(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.c.accept(SignalLogs, req)
+
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
@@ -0,0 +1,149 @@
+package fakecollector
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+
+	maxBodySize = 32 << 20
+)
+
+// httpHandler serves the OTLP HTTP paths, with the request encoded as
+// protobuf or JSON according to its Content-Type.
+func (c *Collector) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/traces", func(w http.ResponseWriter, r *http.Request) {
+		c.serveHTTP(w, r, SignalTraces, &coltracepb.ExportTraceServiceRequest{}, &coltracepb.ExportTraceServiceResponse{})
+	})
+
+	mux.HandleFunc("/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		c.serveHTTP(w, r, SignalMetrics, &colmetricpb.ExportMetricsServiceRequest{}, &colmetricpb.ExportMetricsServiceResponse{})
+	})
+
+	mux.HandleFunc("/v1/logs", func(w http.ResponseWriter, r *http.Request) {
+		c.serveHTTP(w, r, SignalLogs, &collogspb.ExportLogsServiceRequest{}, &collogspb.ExportLogsServiceResponse{})
+	})
+
+	return mux
+}
+
+// serveHTTP decodes the request into req and accepts it before writing
+// resp, so the telemetry is stored when the exporter gets the response.
+func (c *Collector) serveHTTP(w http.ResponseWriter, r *http.Request, signal Signal, req, resp proto.Message) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case contentTypeJSON:
+		err = unmarshalJSON(body, req)
+	case contentTypeProtobuf, "":
+		contentType = contentTypeProtobuf
+		err = proto.Unmarshal(body, req)
+	default:
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if f, ok := c.receive(signal); ok && f.writeHTTP(w, r) {
+		return
+	}
+
+	var out []byte
+	if contentType == contentTypeJSON {
+		out, err = protojson.Marshal(resp)
+	} else {
+		out, err = proto.Marshal(resp)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	c.accept(signal, req)
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(out)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	var body io.Reader = http.MaxBytesReader(nil, r.Body, maxBodySize)
+
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = io.LimitReader(gz, maxBodySize)
+	}
+
+	return io.ReadAll(body)
+}
+
+/*
+unmarshalJSON decodes the OTLP JSON encoding, where the trace and span IDs
+are hex strings, unlike the base64 strings of the protobuf JSON mapping, and
+the unknown fields are ignored.
+*/
+func unmarshalJSON(body []byte, msg proto.Message) error {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return err
+	}
+	base64EncodeIDs(doc)
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, msg)
+}
+
+func base64EncodeIDs(v interface{}) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			if s, ok := child.(string); ok && (k == "traceId" || k == "spanId" || k == "parentSpanId") {
+				if raw, err := hex.DecodeString(s); err == nil {
+					node[k] = base64.StdEncoding.EncodeToString(raw)
+				}
+				continue
+			}
+			base64EncodeIDs(child)
+		}
+	case []interface{}:
+		for _, child := range node {
+			base64EncodeIDs(child)
+		}
+	}
+}
@@ -3,12 +3,10 @@ package otelpp
 import (
 	"context"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/metric/global"
-	"go.opentelemetry.io/otel/propagation"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"time"
 
@@ -24,101 +22,21 @@ The returned Tracing and Metric structure can be used to create or shutdown
 the processor.
 */
 func NewGRPCProvider(ctx context.Context, opts ...OptionProvider) (tracing Telemetry, metric Meter, err error) {
-
-	cfg := buildConfig(opts...)
-
-	if hasMissingConfigInfo(cfg) {
-		return nil, nil, ErrMissingConfig
-	}
-
-	setErrorHandler(cfg)
-
-	if cfg.traceEnable() {
-		tracing, err = newGRPCTracerProvider(ctx, cfg)
-		if err != nil {
-			return nil, nil, errors.Wrap(err, err.Error())
-		}
-	}
-
-	if cfg.metricEnable() {
-		metric, err = newGRPCMetricProvider(ctx, cfg)
-		if err != nil {
-			return nil, nil, errors.Wrap(err, err.Error())
-		}
-	}
-
-	return
+	return NewProvider(ctx, append(opts, forceProtocol(ProtocolGRPC))...)
 }
 
-/*
-newGRPCMetricProvider creates and sets the global trace provider
-configured with an OTel Exporter that exports the collected data via gRPC.
-
-The returned Metric structure can be used to create or shutdown
-the processor.
-*/
-func newGRPCMetricProvider(ctx context.Context, cfg Config) (*Metric, error) {
-	mp, err := grpcMetricProvider(ctx, cfg)
-	if err != nil {
-		return nil, errors.Wrap(err, err.Error())
-	}
-
-	global.SetMeterProvider(mp)
-	meter := mp.Meter(cfg.ServiceName)
-
-	return &Metric{
-		provider: mp,
-		meter:    meter,
-	}, nil
-}
-
-/*
-newGRPCTracerProvider creates and sets the global trace provider
-configured with an OTel Exporter that exports the collected spans via gRPC.
-
-The returned Tracing structure can be used to create new spans or shutdown
-the span processor.
-*/
-func newGRPCTracerProvider(ctx context.Context, cfg Config) (*Tracing, error) {
-	tp, err := grpcTraceProvider(ctx, cfg)
-	if err != nil {
-		return nil, errors.Wrap(err, err.Error())
-	}
-
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-	tracer := tp.Tracer(cfg.ServiceName)
-
-	return &Tracing{
-		provider: tp,
-		tracer:   tracer,
-	}, nil
-}
-
-func grpcTraceProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
-	res, err := createResource(ctx, cfg)
-	if err != nil {
-		return nil, errors.Wrap(err, err.Error())
-	}
-
+func grpcTraceClient(ctx context.Context, cfg Config) (otlptrace.Client, error) {
 	timeout := 10 * time.Second
 	if cfg.ValidTimeout() {
 		timeout = cfg.Timeout
 	}
 
-	conn, err := createGrpcConn(ctx, cfg.TraceEndpoint, cfg.Insecure, cfg.GRPCBlockConn, timeout)
+	conn, err := createGrpcConn(ctx, cfg.TraceEndpoint, cfg, timeout)
 	if err != nil {
 		return nil, errors.Wrap(err, err.Error())
 	}
 
-	exp, err := otlptracegrpc.New(ctx,
-		withOtlpGRPCOptions(cfg, conn)...,
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, err.Error())
-	}
-
-	return createTracerProvider(exp, res, cfg)
+	return otlptracegrpc.NewClient(withOtlpGRPCOptions(cfg, conn)...), nil
 }
 
 func withOtlpGRPCOptions(cfg Config, conn *grpc.ClientConn) []otlptracegrpc.Option {
@@ -133,12 +51,13 @@ func withOtlpGRPCOptions(cfg Config, conn *grpc.ClientConn) []otlptracegrpc.Opti
 	if cfg.ValidTimeout() {
 		opts = append(opts, otlptracegrpc.WithTimeout(cfg.Timeout))
 	}
-	if cfg.RetryConfig != (RetryConfig{}) {
+	if cfg.RetryConfig.set() {
+		rc := cfg.RetryConfig.withDefaults()
 		opts = append(opts, otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
-			Enabled:         cfg.Enabled,
-			InitialInterval: cfg.InitialInterval,
-			MaxInterval:     cfg.MaxInterval,
-			MaxElapsedTime:  cfg.MaxElapsedTime,
+			Enabled:         rc.Enabled,
+			InitialInterval: rc.InitialInterval,
+			MaxInterval:     rc.MaxInterval,
+			MaxElapsedTime:  rc.MaxElapsedTime,
 		}))
 	}
 
@@ -150,14 +69,20 @@ func withOtlpGRPCOptions(cfg Config, conn *grpc.ClientConn) []otlptracegrpc.Opti
 	return opts
 }
 
-func createGrpcConn(ctx context.Context, endpoint string, insec, block bool, timeout time.Duration) (*grpc.ClientConn, error) {
+func createGrpcConn(ctx context.Context, endpoint string, cfg Config, timeout time.Duration) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
 
-	if insec {
+	if cfg.Insecure {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		tlsCfg, err := createTLSConfig(cfg, endpoint)
+		if err != nil {
+			return nil, errors.Wrap(err, err.Error())
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)))
 	}
 
-	if block {
+	if cfg.GRPCBlockConn {
 		opts = append(opts, grpc.WithBlock())
 	}
 
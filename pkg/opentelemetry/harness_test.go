@@ -0,0 +1,146 @@
+package otelpp_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	otelpp "otlp-stack/pkg/opentelemetry"
+	"otlp-stack/pkg/opentelemetry/otelpptest"
+)
+
+func TestRuleSampler_Harness(t *testing.T) {
+	zero := 0.0
+	sampler, err := otelpp.NewRuleSampler([]otelpp.SamplingRule{
+		{Name: "GET /health*", Drop: true},
+		{SpanKind: "consumer", Ratio: &zero},
+	}, sdktrace.AlwaysSample())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := otelpptest.New(otelpptest.WithSampler(sampler))
+	ctx := context.Background()
+
+	for _, name := range []string{"GET /healthz", "GET /users"} {
+		ctx, span := h.Telemetry().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+		_, child := h.Telemetry().Start(ctx, name+" query")
+		child.End()
+		span.End()
+	}
+
+	_, consumer := h.Telemetry().Start(ctx, "orders process", trace.WithSpanKind(trace.SpanKindConsumer))
+	consumer.End()
+
+	h.AssertSpan(t, "GET /users")
+	h.AssertSpan(t, "GET /users query")
+
+	for _, name := range []string{"GET /healthz", "GET /healthz query", "orders process"} {
+		if _, ok := h.FindSpan(name); ok {
+			t.Errorf("span %q was sampled", name)
+		}
+	}
+}
+
+func TestRateLimitingSampler_Harness(t *testing.T) {
+	tests := []struct {
+		tracesPerSecond float64
+		want            int
+	}{
+		{tracesPerSecond: 3, want: 3},
+		{tracesPerSecond: 0, want: 0},
+		{tracesPerSecond: -1, want: 0},
+	}
+
+	for _, tt := range tests {
+		h := otelpptest.New(otelpptest.WithSampler(otelpp.NewRateLimitingSampler(tt.tracesPerSecond)))
+
+		// the burst of one second of traffic is sampled, the next root spans
+		// of the same second are dropped
+		for i := 0; i < 10; i++ {
+			_, span := h.Telemetry().Start(context.Background(), "job")
+			span.End()
+		}
+
+		if got := len(h.Spans()); got != tt.want {
+			t.Errorf("sampled root spans at %g tps = %d, want %d", tt.tracesPerSecond, got, tt.want)
+		}
+	}
+}
+
+func TestRuleView_Harness(t *testing.T) {
+	view, err := otelpp.NewRuleView([]otelpp.ViewRule{
+		{Name: "http.requests", Rename: "requests", AttributeKeys: []string{"route"}},
+		{Name: "*.bytes", Kind: "histogram", Buckets: []float64{100, 1000}},
+		{Name: "debug.*", Drop: true},
+	}, otelpp.NewMetricHistogramBucketView())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := otelpptest.New(otelpptest.WithViews(view))
+	ctx := context.Background()
+
+	requests, err := h.Meter().Int64Counter("http.requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests.Add(ctx, 1, attribute.String("route", "/a"), attribute.String("user.id", "1"))
+	requests.Add(ctx, 1, attribute.String("route", "/a"), attribute.String("user.id", "2"))
+
+	debug, err := h.Meter().Int64Counter("debug.cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	debug.Add(ctx, 1)
+
+	for name, value := range map[string]float64{"payload.bytes": 512, "latency": 700} {
+		hist, err := h.Meter().Float64Histogram(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hist.Record(ctx, value)
+	}
+
+	// renamed, and the user.id attribute removed
+	h.AssertCounter(t, "requests", 2, attribute.String("route", "/a"))
+
+	for _, name := range []string{"http.requests", "debug.cache"} {
+		if _, err = h.FindMetric(name); !errors.Is(err, otelpptest.ErrMetricNotFound) {
+			t.Errorf("FindMetric(%q): %v, want ErrMetricNotFound", name, err)
+		}
+	}
+
+	bounds := map[string][]float64{
+		"payload.bytes": {100, 1000},
+		"latency":       {500, 1000, 10000, 30000, 60000},
+	}
+	for name, want := range bounds {
+		m, err := h.FindMetric(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hist, ok := m.Data.(metricdata.Histogram)
+		if !ok || len(hist.DataPoints) != 1 {
+			t.Fatalf("%s: %#v, want a histogram with one data point", name, m.Data)
+		}
+		if got := hist.DataPoints[0].Bounds; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s bounds = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRuleView_EmptyCriteria(t *testing.T) {
+	_, err := otelpp.NewRuleView([]otelpp.ViewRule{
+		{Name: "debug.*", Drop: true},
+		{Drop: true},
+	}, nil)
+	if err == nil {
+		t.Fatal("NewRuleView accepted a rule without name, kind nor scope")
+	}
+}
@@ -3,11 +3,8 @@ package otelpp
 import (
 	"context"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/metric/global"
-	"go.opentelemetry.io/otel/propagation"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 /*
@@ -18,97 +15,33 @@ The returned Tracing and Metric structure can be used to create new spans or shu
 the processor.
 */
 func NewHTTPProvider(ctx context.Context, opts ...OptionProvider) (Telemetry, Meter, error) {
-	var (
-		tracing *Tracing
-		metric  *Metric
-		err     error
-	)
-
-	cfg := buildConfig(opts...)
-
-	if hasMissingConfigInfo(cfg) {
-		return nil, nil, ErrMissingConfig
-	}
-
-	setErrorHandler(cfg)
-
-	if cfg.traceEnable() {
-		tracing, err = newHTTPTracerProvider(ctx, cfg)
-		if err != nil {
-			return nil, nil, errors.Wrap(err, err.Error())
-		}
-	}
-
-	if cfg.metricEnable() {
-		metric, err = newHTTPMetricProvider(ctx, cfg)
-		if err != nil {
-			return nil, nil, errors.Wrap(err, err.Error())
-		}
-	}
-
-	return tracing, metric, nil
+	return NewProvider(ctx, append(opts, forceProtocol(ProtocolHTTPProtobuf))...)
 }
 
-func newHTTPMetricProvider(ctx context.Context, cfg Config) (*Metric, error) {
-	mp, err := httpMetricExporter(ctx, cfg)
+func httpTraceClient(cfg Config) (otlptrace.Client, error) {
+	opts, err := withOtlpTraceHTTPOptions(cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, err.Error())
 	}
 
-	global.SetMeterProvider(mp)
-	meter := mp.Meter(cfg.ServiceName)
-
-	return &Metric{
-		provider: mp,
-		meter:    meter,
-	}, nil
+	return otlptracehttp.NewClient(opts...), nil
 }
 
-/*
-newHTTPTracerProvider creates and sets the global trace provider
-configured with an OTel Exporter that exports the collected spans via HTTP.
-
-The returned Tracing structure can be used to create new spans or shutdown
-the span processor.
-*/
-func newHTTPTracerProvider(ctx context.Context, cfg Config) (*Tracing, error) {
-	tp, err := httpTraceProvider(ctx, cfg)
-	if err != nil {
-		return nil, errors.Wrap(err, err.Error())
-	}
-
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-	tracer := tp.Tracer(cfg.ServiceName)
-
-	return &Tracing{
-		provider: tp,
-		tracer:   tracer,
-	}, nil
-}
-
-func httpTraceProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
-	res, err := createResource(ctx, cfg)
-	if err != nil {
-		return nil, errors.Wrap(err, err.Error())
-	}
-
-	exp, err := otlptracehttp.New(ctx,
-		withOtlpTraceHTTPOptions(cfg)...,
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, err.Error())
-	}
-
-	return createTracerProvider(exp, res, cfg)
-}
-
-func withOtlpTraceHTTPOptions(cfg Config) []otlptracehttp.Option {
+func withOtlpTraceHTTPOptions(cfg Config) ([]otlptracehttp.Option, error) {
 	endpoint := trimEndpoint(cfg.TraceEndpoint)
-	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint),
+		otlptracehttp.WithURLPath(signalPath(cfg.tracePath, defaultTracesPath)),
+	}
 
 	if cfg.Insecure {
 		opts = append(opts, otlptracehttp.WithInsecure())
+	} else if cfg.tlsEnable() {
+		tlsCfg, err := createTLSConfig(cfg, endpoint)
+		if err != nil {
+			return nil, errors.Wrap(err, err.Error())
+		}
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
 	}
 	if len(cfg.Headers) > 0 {
 		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
@@ -116,12 +49,13 @@ func withOtlpTraceHTTPOptions(cfg Config) []otlptracehttp.Option {
 	if cfg.ValidTimeout() {
 		opts = append(opts, otlptracehttp.WithTimeout(cfg.Timeout))
 	}
-	if cfg.RetryConfig != (RetryConfig{}) {
+	if cfg.RetryConfig.set() {
+		rc := cfg.RetryConfig.withDefaults()
 		opts = append(opts, otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
-			Enabled:         cfg.Enabled,
-			InitialInterval: cfg.InitialInterval,
-			MaxInterval:     cfg.MaxInterval,
-			MaxElapsedTime:  cfg.MaxElapsedTime,
+			Enabled:         rc.Enabled,
+			InitialInterval: rc.InitialInterval,
+			MaxInterval:     rc.MaxInterval,
+			MaxElapsedTime:  rc.MaxElapsedTime,
 		}))
 	}
 
@@ -129,5 +63,5 @@ func withOtlpTraceHTTPOptions(cfg Config) []otlptracehttp.Option {
 		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
 	}
 
-	return opts
+	return opts, nil
 }
@@ -0,0 +1,133 @@
+package otelpp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Compile-time check the JSON clients implement the SDK interfaces.
+var (
+	_ otlptrace.Client   = (*jsonTraceClient)(nil)
+	_ sdkmetric.Exporter = (*jsonMetricExporter)(nil)
+)
+
+// MarshalJSON encodes msg, an OTLP message, as OTLP/JSON. The protobuf JSON
+// mapping encodes bytes as base64 and enums as names, but OTLP requires trace
+// and span IDs as hex and enums as numbers.
+func MarshalJSON(msg proto.Message) ([]byte, error) {
+	body, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err = json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	hexEncodeIDs(doc)
+
+	return json.Marshal(doc)
+}
+
+func hexEncodeIDs(v interface{}) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			if s, ok := child.(string); ok && (k == "traceId" || k == "spanId" || k == "parentSpanId") {
+				if raw, err := base64.StdEncoding.DecodeString(s); err == nil {
+					node[k] = hex.EncodeToString(raw)
+				}
+				continue
+			}
+			hexEncodeIDs(child)
+		}
+	case []interface{}:
+		for _, child := range node {
+			hexEncodeIDs(child)
+		}
+	}
+}
+
+// jsonTraceClient is the otlptrace.Client used by the http/json protocol.
+type jsonTraceClient struct {
+	*otlpHTTPClient
+}
+
+func (c *jsonTraceClient) Start(_ context.Context) error {
+	return nil
+}
+
+func (c *jsonTraceClient) Stop(_ context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+func (c *jsonTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	return c.send(ctx, &coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+}
+
+func httpJSONTraceClient(cfg Config) (otlptrace.Client, error) {
+	client, err := newOtlpHTTPClient(cfg, cfg.TraceEndpoint, signalPath(cfg.tracePath, defaultTracesPath), true)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	return &jsonTraceClient{client}, nil
+}
+
+// jsonMetricExporter is the sdkmetric.Exporter used by the http/json protocol.
+type jsonMetricExporter struct {
+	*otlpHTTPClient
+	temporality sdkmetric.TemporalitySelector
+	aggregation sdkmetric.AggregationSelector
+}
+
+func httpJSONMetricExporter(_ context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	client, err := newOtlpHTTPClient(cfg, cfg.MetricEndpoint, signalPath(cfg.metricPath, defaultMetricsPath), true)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	return &jsonMetricExporter{
+		otlpHTTPClient: client,
+		temporality:    cfg.temporalitySelector(),
+		aggregation:    cfg.aggregationSelector(),
+	}, nil
+}
+
+func (e *jsonMetricExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return e.temporality(k)
+}
+
+func (e *jsonMetricExporter) Aggregation(k sdkmetric.InstrumentKind) aggregation.Aggregation {
+	return e.aggregation(k)
+}
+
+func (e *jsonMetricExporter) Export(ctx context.Context, rm metricdata.ResourceMetrics) error {
+	return e.send(ctx, &colmetricpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{resourceMetricsToProto(&rm)},
+	})
+}
+
+func (e *jsonMetricExporter) ForceFlush(_ context.Context) error {
+	return nil
+}
+
+func (e *jsonMetricExporter) Shutdown(_ context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
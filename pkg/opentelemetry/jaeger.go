@@ -2,9 +2,7 @@ package otelpp
 
 import (
 	"context"
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
@@ -22,35 +20,15 @@ func NewJaegerTracerProvider(ctx context.Context, cfg Config) (*Tracing, error)
 
 	setErrorHandler(cfg)
 
-	tp, err := jaegerTraceProvider(ctx, cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-	tracer := tp.Tracer(cfg.ServiceName)
+	cfg.TraceProtocol = ProtocolJaeger
 
-	return &Tracing{
-		provider: tp,
-		tracer:   tracer,
-	}, nil
+	return newTracerProvider(ctx, cfg)
 }
 
-func jaegerTraceProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
-	res, err := createResource(ctx, cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	exp, err := jaeger.New(
+func jaegerTraceExporter(cfg Config) (sdktrace.SpanExporter, error) {
+	return jaeger.New(
 		jaeger.WithCollectorEndpoint(
 			jaeger.WithEndpoint(cfg.TraceEndpoint),
 		),
 	)
-	if err != nil {
-		return nil, err
-	}
-
-	return createTracerProvider(exp, res, cfg)
 }
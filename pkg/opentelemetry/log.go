@@ -0,0 +1,327 @@
+package otelpp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+const (
+	defaultLogSendInterval    = time.Second
+	defaultLogMaxQueueSize    = 2048
+	defaultLogMaxExportBatch  = 512
+	defaultLogExporterTimeout = 30 * time.Second
+)
+
+var ErrMissingLogConfig = errors.New("missing required fields: AppEnv, LogEndpoint, ServiceName")
+
+// Compile-time check LoggerProvider implements Logging.
+var _ Logging = (*LoggerProvider)(nil)
+
+// Logging defines methods to emit log records and handle the log processor.
+type Logging interface {
+	Emit(ctx context.Context, record LogRecord)
+	ForceFlush(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// Severity is the OTLP severity number of a LogRecord.
+type Severity int32
+
+const (
+	SeverityTrace Severity = 1
+	SeverityDebug Severity = 5
+	SeverityInfo  Severity = 9
+	SeverityWarn  Severity = 13
+	SeverityError Severity = 17
+	SeverityFatal Severity = 21
+)
+
+// LogRecord is a single log entry sent through the logs pipeline.
+// When TraceID and SpanID are empty they are taken from the span in the
+// context passed to Emit.
+type LogRecord struct {
+	Timestamp    time.Time
+	Severity     Severity
+	SeverityText string
+	Body         string
+	Attributes   []attribute.KeyValue
+	TraceID      trace.TraceID
+	SpanID       trace.SpanID
+	TraceFlags   trace.TraceFlags
+}
+
+// LoggerProvider is the structure to be used for handling OTel logs.
+// Records are batched and exported in the background.
+type LoggerProvider struct {
+	resource *logspb.ResourceLogs
+	exporter logExporter
+	interval time.Duration
+	timeout  time.Duration
+
+	// mu guards stopped, so that no record is enqueued once Shutdown has
+	// started draining the queue
+	mu      sync.RWMutex
+	stopped bool
+
+	queue    chan *logspb.LogRecord
+	flush    chan chan error
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// logExporter sends a batch of OTLP log records to the collector.
+type logExporter interface {
+	export(ctx context.Context, logs []*logspb.ResourceLogs) error
+	shutdown(ctx context.Context) error
+}
+
+/*
+NewLoggerProvider creates a LoggerProvider configured with an OTel Exporter
+that exports the collected log records using the log protocol.
+
+The returned LoggerProvider structure can be used to emit records or shutdown
+the processor.
+*/
+func NewLoggerProvider(ctx context.Context, opts ...OptionProvider) (*LoggerProvider, error) {
+	cfg := buildConfig(opts...)
+
+	if (cfg.AppEnv < 1 || cfg.AppEnv > 3) || cfg.ServiceName == "" || !cfg.logEnable() {
+		return nil, ErrMissingLogConfig
+	}
+
+	setErrorHandler(cfg)
+
+	cfg = cfg.signalConfig(envSignalLogs)
+
+	res, err := createResource(ctx, cfg)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, err.Error())
+	}
+
+	exp, err := newLogExporter(ctx, cfg)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, err.Error())
+	}
+
+	return newLoggerProvider(exp, res, cfg), nil
+}
+
+func newLogExporter(ctx context.Context, cfg Config) (logExporter, error) {
+	switch cfg.logProtocol() {
+	case ProtocolGRPC:
+		return grpcLogExporter(ctx, cfg)
+	case ProtocolHTTPProtobuf:
+		return httpLogExporter(cfg, false)
+	case ProtocolHTTPJSON:
+		return httpLogExporter(cfg, true)
+	default:
+		return nil, ErrInvalidLogProtocol
+	}
+}
+
+func newLoggerProvider(exp logExporter, res *resource.Resource, cfg Config) *LoggerProvider {
+	interval := defaultLogSendInterval
+	if cfg.sendIntervalLog != nil {
+		interval = *cfg.sendIntervalLog
+	}
+
+	timeout := defaultLogExporterTimeout
+	if cfg.ValidTimeout() {
+		timeout = cfg.Timeout
+	}
+
+	lp := &LoggerProvider{
+		resource: &logspb.ResourceLogs{
+			Resource:  resourceToProto(res),
+			SchemaUrl: res.SchemaURL(),
+		},
+		exporter: exp,
+		interval: interval,
+		timeout:  timeout,
+		queue:    make(chan *logspb.LogRecord, defaultLogMaxQueueSize),
+		flush:    make(chan chan error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go lp.processQueue(cfg.ServiceName)
+
+	return lp
+}
+
+// Emit enqueues the record to be exported. The record is dropped when
+// the queue is full or the provider has been shut down.
+func (l *LoggerProvider) Emit(ctx context.Context, record LogRecord) {
+	if !record.TraceID.IsValid() {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			record.TraceID = sc.TraceID()
+			record.SpanID = sc.SpanID()
+			record.TraceFlags = sc.TraceFlags()
+		}
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.stopped {
+		return
+	}
+
+	select {
+	case l.queue <- logRecordToProto(record):
+	default:
+		otel.Handle(errors.New("log record dropped: queue is full"))
+	}
+}
+
+// ForceFlush exports all the queued records.
+func (l *LoggerProvider) ForceFlush(ctx context.Context) error {
+	result := make(chan error, 1)
+
+	select {
+	case l.flush <- result:
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown exports the queued records and stops the exporter, the next calls
+// do nothing.
+func (l *LoggerProvider) Shutdown(ctx context.Context) error {
+	var err error
+	l.stopOnce.Do(func() {
+		l.mu.Lock()
+		l.stopped = true
+		close(l.stop)
+		l.mu.Unlock()
+
+		select {
+		case <-l.done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		if shutdownErr := l.exporter.shutdown(ctx); err == nil {
+			err = shutdownErr
+		}
+	})
+
+	return err
+}
+
+func (l *LoggerProvider) processQueue(scopeName string) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	scope := &commonpb.InstrumentationScope{Name: scopeName}
+	batch := make([]*logspb.LogRecord, 0, defaultLogMaxExportBatch)
+
+	export := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+		defer cancel()
+
+		err := l.exporter.export(ctx, []*logspb.ResourceLogs{{
+			Resource:  l.resource.Resource,
+			SchemaUrl: l.resource.SchemaUrl,
+			ScopeLogs: []*logspb.ScopeLogs{{Scope: scope, LogRecords: batch}},
+		}})
+		batch = make([]*logspb.LogRecord, 0, defaultLogMaxExportBatch)
+		return err
+	}
+
+	drain := func() error {
+		var errs []error
+		for {
+			select {
+			case rec := <-l.queue:
+				batch = append(batch, rec)
+				if len(batch) >= defaultLogMaxExportBatch {
+					if err := export(); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			default:
+				if err := export(); err != nil {
+					errs = append(errs, err)
+				}
+				return errors.Join(errs...)
+			}
+		}
+	}
+
+	for {
+		select {
+		case rec := <-l.queue:
+			batch = append(batch, rec)
+			if len(batch) >= defaultLogMaxExportBatch {
+				if err := export(); err != nil {
+					otel.Handle(err)
+				}
+			}
+		case <-ticker.C:
+			if err := export(); err != nil {
+				otel.Handle(err)
+			}
+		case result := <-l.flush:
+			result <- drain()
+		case <-l.stop:
+			if err := drain(); err != nil {
+				otel.Handle(err)
+			}
+			return
+		}
+	}
+}
+
+func logRecordToProto(record LogRecord) *logspb.LogRecord {
+	observed := time.Now()
+	timestamp := record.Timestamp
+	if timestamp.IsZero() {
+		timestamp = observed
+	}
+
+	out := &logspb.LogRecord{
+		TimeUnixNano:         timeToUnixNano(timestamp),
+		ObservedTimeUnixNano: timeToUnixNano(observed),
+		SeverityNumber:       logspb.SeverityNumber(record.Severity),
+		SeverityText:         record.SeverityText,
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: record.Body}},
+		Attributes:           attributesToProto(record.Attributes),
+	}
+
+	if record.TraceID.IsValid() {
+		out.TraceId = record.TraceID[:]
+		out.Flags = uint32(record.TraceFlags)
+	}
+	if record.SpanID.IsValid() {
+		out.SpanId = record.SpanID[:]
+	}
+
+	return out
+}
@@ -0,0 +1,91 @@
+package otelpp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestLoggerProvider_EmitShutdown(t *testing.T) {
+	exp := &fakeLogExporter{}
+	interval := time.Hour
+
+	cfg := Config{ServiceName: "test"}
+	cfg.sendIntervalLog = &interval
+	lp := newLoggerProvider(exp, resource.Empty(), cfg)
+
+	// the records emitted while shutting down are either exported or dropped,
+	// never left in the queue
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				lp.Emit(context.Background(), LogRecord{Body: "concurrent"})
+			}
+		}()
+	}
+
+	lp.Emit(context.Background(), LogRecord{Body: "before"})
+	if err := lp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	lp.Emit(context.Background(), LogRecord{Body: "after"})
+
+	if n := len(lp.queue); n != 0 {
+		t.Errorf("%d records left in the queue", n)
+	}
+
+	bodies := exp.bodies()
+	if len(bodies) == 0 || bodies[len(bodies)-1] == "after" {
+		t.Errorf("exported %d records, want the ones emitted before the shutdown only", len(bodies))
+	}
+	if !exp.stopped {
+		t.Error("exporter not shut down")
+	}
+}
+
+// fakeLogExporter records the exported log records.
+type fakeLogExporter struct {
+	mu      sync.Mutex
+	records []*logspb.LogRecord
+	stopped bool
+}
+
+func (e *fakeLogExporter) export(_ context.Context, logs []*logspb.ResourceLogs) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, rl := range logs {
+		for _, sl := range rl.GetScopeLogs() {
+			e.records = append(e.records, sl.GetLogRecords()...)
+		}
+	}
+	return nil
+}
+
+func (e *fakeLogExporter) shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.stopped = true
+	return nil
+}
+
+func (e *fakeLogExporter) bodies() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var bodies []string
+	for _, r := range e.records {
+		bodies = append(bodies, r.GetBody().GetStringValue())
+	}
+	return bodies
+}
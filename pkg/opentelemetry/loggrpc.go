@@ -0,0 +1,70 @@
+package otelpp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"time"
+
+	"compress/gzip"
+	egzip "google.golang.org/grpc/encoding/gzip"
+)
+
+// grpcLogClient exports log records with the OTLP gRPC logs service.
+type grpcLogClient struct {
+	conn     *grpc.ClientConn
+	client   collogspb.LogsServiceClient
+	headers  metadata.MD
+	callOpts []grpc.CallOption
+	retry    RetryConfig
+}
+
+func grpcLogExporter(ctx context.Context, cfg Config) (logExporter, error) {
+	timeout := 10 * time.Second
+	if cfg.ValidTimeout() {
+		timeout = cfg.Timeout
+	}
+
+	conn, err := createGrpcConn(ctx, cfg.LogEndpoint, cfg, timeout)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	c := &grpcLogClient{
+		conn:    conn,
+		client:  collogspb.NewLogsServiceClient(conn),
+		headers: metadata.New(cfg.Headers),
+		retry:   cfg.RetryConfig,
+	}
+
+	if cfg.UseGzipCompression {
+		c.callOpts = append(c.callOpts, grpc.UseCompressor(egzip.Name))
+		_ = egzip.SetLevel(gzip.BestSpeed)
+	}
+
+	return c, nil
+}
+
+func (c *grpcLogClient) export(ctx context.Context, logs []*logspb.ResourceLogs) error {
+	if len(c.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, c.headers)
+	}
+
+	req := &collogspb.ExportLogsServiceRequest{ResourceLogs: logs}
+	err := c.retry.retry(ctx, func(ctx context.Context) error {
+		_, err := c.client.Export(ctx, req, c.callOpts...)
+		return grpcRetryable(err)
+	})
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	return nil
+}
+
+func (c *grpcLogClient) shutdown(_ context.Context) error {
+	return c.conn.Close()
+}
@@ -0,0 +1,32 @@
+package otelpp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// httpLogClient exports log records with OTLP over HTTP, encoded as
+// protobuf or JSON.
+type httpLogClient struct {
+	*otlpHTTPClient
+}
+
+func httpLogExporter(cfg Config, json bool) (logExporter, error) {
+	client, err := newOtlpHTTPClient(cfg, cfg.LogEndpoint, signalPath(cfg.logPath, defaultLogsPath), json)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	return &httpLogClient{client}, nil
+}
+
+func (c *httpLogClient) export(ctx context.Context, logs []*logspb.ResourceLogs) error {
+	return c.send(ctx, &collogspb.ExportLogsServiceRequest{ResourceLogs: logs})
+}
+
+func (c *httpLogClient) shutdown(_ context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
@@ -1,24 +1,14 @@
 package otelpp
 
-//go:generate mockgen -destination=./mocks/mock_meter.go -package=mocks github.com/PicPay/lib-go-otel/v2 Meter
-//go:generate mockgen -destination=./mocks/mock_async_int64.go -package=mocks github.com/PicPay/lib-go-otel/v2 Int64ObservableCounter
-//go:generate mockgen -destination=./mocks/mock_async_float64.go -package=mocks github.com/PicPay/lib-go-otel/v2 Float64ObservableCounter
-//go:generate mockgen -destination=./mocks/mock_sync_int64.go -package=mocks github.com/PicPay/lib-go-otel/v2 Int64Counter
-//go:generate mockgen -destination=./mocks/mock_sync_float64.go -package=mocks github.com/PicPay/lib-go-otel/v2 Float64Counter
-//go:generate mockgen -destination=./mocks/mock_float64_observable_up_down_counter.go -package=mocks github.com/PicPay/lib-go-otel/v2 Float64ObservableUpDownCounter
-//go:generate mockgen -destination=./mocks/mock_float64_observable_gauge.go -package=mocks github.com/PicPay/lib-go-otel/v2 Float64ObservableGauge
-//go:generate mockgen -destination=./mocks/mock_int64_observable_up_down_counter.go -package=mocks github.com/PicPay/lib-go-otel/v2 Int64ObservableUpDownCounter
-//go:generate mockgen -destination=./mocks/mock_int64_observable_gauge.go -package=mocks github.com/PicPay/lib-go-otel/v2 Int64ObservableGauge
-//go:generate mockgen -destination=./mocks/mock_float64_up_down_counter.go -package=mocks github.com/PicPay/lib-go-otel/v2 Float64UpDownCounter
-//go:generate mockgen -destination=./mocks/mock_int64_up_down_counter.go -package=mocks github.com/PicPay/lib-go-otel/v2 Int64UpDownCounter
-
 import (
 	"context"
+	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/instrument"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 	"go.opentelemetry.io/otel/sdk/resource"
+	"net/http"
 	"time"
 )
 
@@ -93,8 +83,21 @@ type Int64Histogram interface {
 
 // Metric is the structure to be used for handling OTel metrics.
 type Metric struct {
-	provider *sdkmetric.MeterProvider
-	meter    metric.Meter
+	provider    *sdkmetric.MeterProvider
+	meter       metric.Meter
+	components  []interface{}
+	prometheus  *prometheusEndpoint
+	cardinality *cardinalityLimiter
+}
+
+// NewMetric returns the Metric of mp, creating the instruments with the
+// meter name. NewProvider should be used instead, unless the provider is
+// built by the caller, as done by otelpptest.
+func NewMetric(mp *sdkmetric.MeterProvider, name string) *Metric {
+	return &Metric{
+		provider: mp,
+		meter:    mp.Meter(name),
+	}
 }
 
 // Float64ObservableCounter returns a new instrument identified by name and
@@ -146,14 +149,22 @@ func (m *Metric) Int64ObservableGauge(name string, options ...instrument.Int64Ob
 // configured with options. The instrument is used to synchronously record
 // increasing float64 measurements during a computational operation.
 func (m *Metric) Float64Counter(name string, options ...instrument.Float64Option) (Float64Counter, error) {
-	return m.meter.Float64Counter(name, options...)
+	i, err := m.meter.Float64Counter(name, options...)
+	if err != nil || m.cardinality == nil {
+		return i, err
+	}
+	return limitedFloat64Counter{i, m.cardinality.instrument(name)}, nil
 }
 
 // Float64UpDownCounter returns a new instrument identified by name and
 // configured with options. The instrument is used to synchronously record
 // float64 measurements during a computational operation.
 func (m *Metric) Float64UpDownCounter(name string, options ...instrument.Float64Option) (Float64UpDownCounter, error) {
-	return m.meter.Float64UpDownCounter(name, options...)
+	i, err := m.meter.Float64UpDownCounter(name, options...)
+	if err != nil || m.cardinality == nil {
+		return i, err
+	}
+	return limitedFloat64UpDownCounter{i, m.cardinality.instrument(name)}, nil
 }
 
 // Float64Histogram returns a new instrument identified by name and
@@ -161,28 +172,44 @@ func (m *Metric) Float64UpDownCounter(name string, options ...instrument.Float64
 // the distribution of float64 measurements during a computational
 // operation.
 func (m *Metric) Float64Histogram(name string, options ...instrument.Float64Option) (Float64Histogram, error) {
-	return m.meter.Float64Histogram(name, options...)
+	i, err := m.meter.Float64Histogram(name, options...)
+	if err != nil || m.cardinality == nil {
+		return i, err
+	}
+	return limitedFloat64Histogram{i, m.cardinality.instrument(name)}, nil
 }
 
 // Int64Counter returns a new instrument identified by name and configured
 // with options. The instrument is used to synchronously record increasing
 // int64 measurements during a computational operation.
 func (m *Metric) Int64Counter(name string, options ...instrument.Int64Option) (Int64Counter, error) {
-	return m.meter.Int64Counter(name, options...)
+	i, err := m.meter.Int64Counter(name, options...)
+	if err != nil || m.cardinality == nil {
+		return i, err
+	}
+	return limitedInt64Counter{i, m.cardinality.instrument(name)}, nil
 }
 
 // Int64UpDownCounter returns a new instrument identified by name and
 // configured with options. The instrument is used to synchronously record
 // int64 measurements during a computational operation.
 func (m *Metric) Int64UpDownCounter(name string, options ...instrument.Int64Option) (Int64UpDownCounter, error) {
-	return m.meter.Int64UpDownCounter(name, options...)
+	i, err := m.meter.Int64UpDownCounter(name, options...)
+	if err != nil || m.cardinality == nil {
+		return i, err
+	}
+	return limitedInt64UpDownCounter{i, m.cardinality.instrument(name)}, nil
 }
 
 // Int64Histogram returns a new instrument identified by name and
 // configured with options. The instrument is used to synchronously record
 // the distribution of int64 measurements during a computational operation.
 func (m *Metric) Int64Histogram(name string, options ...instrument.Int64Option) (Int64Histogram, error) {
-	return m.meter.Int64Histogram(name, options...)
+	i, err := m.meter.Int64Histogram(name, options...)
+	if err != nil || m.cardinality == nil {
+		return i, err
+	}
+	return limitedInt64Histogram{i, m.cardinality.instrument(name)}, nil
 }
 
 // RegisterCallback captures the function that will be called during Collect.
@@ -190,21 +217,47 @@ func (m *Metric) RegisterCallback(f metric.Callback, instruments ...instrument.A
 	return m.meter.RegisterCallback(f, instruments...)
 }
 
-// Shutdown shuts down the span processors in the order they were registered.
+// ForceFlush collects and exports the metrics of the readers, returning the
+// error of the exporter.
+func (m *Metric) ForceFlush(ctx context.Context) error {
+	return m.provider.ForceFlush(ctx)
+}
+
+// PrometheusHandler returns the handler of the Prometheus endpoint set by
+// WithPrometheus, serving the text and OpenMetrics formats, e.g. to route
+// /metrics of the application with gin.WrapH. It returns nil when the
+// endpoint is not set.
+func (m *Metric) PrometheusHandler() http.Handler {
+	if m.prometheus == nil {
+		return nil
+	}
+	return m.prometheus.handler
+}
+
+// Shutdown shuts down the span processors in the order they were registered,
+// and the Prometheus endpoint server.
 func (m *Metric) Shutdown(ctx context.Context) error {
+	if err := m.prometheus.shutdown(ctx); err != nil {
+		return err
+	}
 	return m.provider.Shutdown(ctx)
 }
 
-func createMetricProvider(r *resource.Resource, exp sdkmetric.Exporter, cfg Config) (*sdkmetric.MeterProvider, error) {
-
-	reader := createMetricReader(exp, cfg)
-	views := createMetricViews(cfg)
+func createMetricProvider(r *resource.Resource, readers []sdkmetric.Reader, cfg Config) (*sdkmetric.MeterProvider, error) {
+	views, err := createMetricViews(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
 
-	return sdkmetric.NewMeterProvider(
+	opts := []sdkmetric.Option{
 		sdkmetric.WithResource(r),
-		sdkmetric.WithReader(reader),
 		sdkmetric.WithView(views...),
-	), nil
+	}
+	for _, reader := range readers {
+		opts = append(opts, sdkmetric.WithReader(reader))
+	}
+
+	return sdkmetric.NewMeterProvider(opts...), nil
 }
 
 func createMetricReader(e sdkmetric.Exporter, cfg Config) sdkmetric.Reader {
@@ -214,7 +267,9 @@ func createMetricReader(e sdkmetric.Exporter, cfg Config) sdkmetric.Reader {
 		opts = append(opts, sdkmetric.WithInterval(*cfg.sendIntervalMetric))
 	}
 
-	if cfg.ValidTimeout() {
+	if cfg.exportTimeoutMetric != nil {
+		opts = append(opts, sdkmetric.WithTimeout(*cfg.exportTimeoutMetric))
+	} else if cfg.ValidTimeout() {
 		opts = append(opts, sdkmetric.WithTimeout(cfg.Timeout))
 	}
 
@@ -225,14 +280,29 @@ func createMetricReader(e sdkmetric.Exporter, cfg Config) sdkmetric.Reader {
 	return sdkmetric.NewPeriodicReader(e, opts...)
 }
 
-func createMetricViews(cfg Config) []sdkmetric.View {
+// createMetricViews returns the views of WithMetricViews, or the default
+// histogram bucket views, composed with the rules of WithMetricViewRules.
+func createMetricViews(cfg Config) ([]sdkmetric.View, error) {
+	views := NewMetricHistogramBucketView()
+
 	if len(cfg.MetricConfig.views) > 0 {
-		return cfg.MetricConfig.views
+		views = cfg.MetricConfig.views
+	}
+
+	if len(cfg.MetricConfig.viewRules) == 0 {
+		return views, nil
+	}
+
+	v, err := NewRuleView(cfg.MetricConfig.viewRules, views)
+	if err != nil {
+		return nil, err
 	}
 
-	return NewMetricHistogramBucketView()
+	return []sdkmetric.View{v}, nil
 }
 
+// NewMetricHistogramBucketView returns the default view, setting the
+// millisecond buckets 500ms, 1s, 10s, 30s and 1m on every histogram.
 func NewMetricHistogramBucketView() []sdkmetric.View {
 	return []sdkmetric.View{
 		sdkmetric.NewView(
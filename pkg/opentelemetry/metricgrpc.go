@@ -12,18 +12,13 @@ import (
 	"google.golang.org/grpc"
 )
 
-func grpcMetricProvider(ctx context.Context, cfg Config) (*sdkmetric.MeterProvider, error) {
-	res, err := createResource(ctx, cfg)
-	if err != nil {
-		return nil, errors.Wrap(err, err.Error())
-	}
-
+func grpcMetricExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
 	timeout := 10 * time.Second
 	if cfg.ValidTimeout() {
 		timeout = cfg.Timeout
 	}
 
-	conn, err := createGrpcConn(ctx, cfg.MetricEndpoint, cfg.Insecure, cfg.GRPCBlockConn, timeout)
+	conn, err := createGrpcConn(ctx, cfg.MetricEndpoint, cfg, timeout)
 	if err != nil {
 		return nil, errors.Wrap(err, err.Error())
 	}
@@ -36,11 +31,15 @@ func grpcMetricProvider(ctx context.Context, cfg Config) (*sdkmetric.MeterProvid
 		return nil, errors.Wrap(err, err.Error())
 	}
 
-	return createMetricProvider(res, exp, cfg)
+	return exp, nil
 }
 
 func withOtlpMetricGRPCOptions(cfg Config, conn *grpc.ClientConn) []otlpmetricgrpc.Option {
-	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithGRPCConn(conn)}
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithGRPCConn(conn),
+		otlpmetricgrpc.WithTemporalitySelector(cfg.temporalitySelector()),
+		otlpmetricgrpc.WithAggregationSelector(cfg.aggregationSelector()),
+	}
 
 	if cfg.Insecure {
 		opts = append(opts, otlpmetricgrpc.WithInsecure())
@@ -51,12 +50,13 @@ func withOtlpMetricGRPCOptions(cfg Config, conn *grpc.ClientConn) []otlpmetricgr
 	if cfg.ValidTimeout() {
 		opts = append(opts, otlpmetricgrpc.WithTimeout(cfg.Timeout))
 	}
-	if cfg.RetryConfig != (RetryConfig{}) {
+	if cfg.RetryConfig.set() {
+		rc := cfg.RetryConfig.withDefaults()
 		opts = append(opts, otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig{
-			Enabled:         cfg.Enabled,
-			InitialInterval: cfg.InitialInterval,
-			MaxInterval:     cfg.MaxInterval,
-			MaxElapsedTime:  cfg.MaxElapsedTime,
+			Enabled:         rc.Enabled,
+			InitialInterval: rc.InitialInterval,
+			MaxInterval:     rc.MaxInterval,
+			MaxElapsedTime:  rc.MaxElapsedTime,
 		}))
 	}
 
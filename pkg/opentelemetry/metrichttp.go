@@ -7,26 +7,37 @@ import (
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 )
 
-func httpMetricExporter(ctx context.Context, cfg Config) (*sdkmetric.MeterProvider, error) {
-	res, err := createResource(ctx, cfg)
+func httpMetricExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	opts, err := withOtlpMetricHTTPOptions(cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, err.Error())
 	}
 
-	exp, err := otlpmetrichttp.New(ctx, withOtlpMetricHTTPOptions(cfg)...)
+	exp, err := otlpmetrichttp.New(ctx, opts...)
 	if err != nil {
 		return nil, errors.Wrap(err, err.Error())
 	}
 
-	return createMetricProvider(res, exp, cfg)
+	return exp, nil
 }
 
-func withOtlpMetricHTTPOptions(cfg Config) []otlpmetrichttp.Option {
+func withOtlpMetricHTTPOptions(cfg Config) ([]otlpmetrichttp.Option, error) {
 	endpoint := trimEndpoint(cfg.MetricEndpoint)
-	opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint)}
+	opts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(endpoint),
+		otlpmetrichttp.WithURLPath(signalPath(cfg.metricPath, defaultMetricsPath)),
+		otlpmetrichttp.WithTemporalitySelector(cfg.temporalitySelector()),
+		otlpmetrichttp.WithAggregationSelector(cfg.aggregationSelector()),
+	}
 
 	if cfg.Insecure {
 		opts = append(opts, otlpmetrichttp.WithInsecure())
+	} else if cfg.tlsEnable() {
+		tlsCfg, err := createTLSConfig(cfg, endpoint)
+		if err != nil {
+			return nil, errors.Wrap(err, err.Error())
+		}
+		opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsCfg))
 	}
 	if len(cfg.Headers) > 0 {
 		opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
@@ -34,12 +45,13 @@ func withOtlpMetricHTTPOptions(cfg Config) []otlpmetrichttp.Option {
 	if cfg.ValidTimeout() {
 		opts = append(opts, otlpmetrichttp.WithTimeout(cfg.Timeout))
 	}
-	if cfg.RetryConfig != (RetryConfig{}) {
+	if cfg.RetryConfig.set() {
+		rc := cfg.RetryConfig.withDefaults()
 		opts = append(opts, otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig{
-			Enabled:         cfg.Enabled,
-			InitialInterval: cfg.InitialInterval,
-			MaxInterval:     cfg.MaxInterval,
-			MaxElapsedTime:  cfg.MaxElapsedTime,
+			Enabled:         rc.Enabled,
+			InitialInterval: rc.InitialInterval,
+			MaxInterval:     rc.MaxInterval,
+			MaxElapsedTime:  rc.MaxElapsedTime,
 		}))
 	}
 
@@ -47,5 +59,5 @@ func withOtlpMetricHTTPOptions(cfg Config) []otlpmetrichttp.Option {
 		opts = append(opts, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
 	}
 
-	return opts
+	return opts, nil
 }
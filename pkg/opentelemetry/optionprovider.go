@@ -1,21 +1,27 @@
 package otelpp
 
 import (
+	"crypto/tls"
 	"github.com/go-logr/logr"
 	"time"
 
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 type OptionProvider func(c *Config)
 type TraceOptionProvider func(c *Config)
 type MetricOptionProvider func(c *Config)
+type LogOptionProvider func(c *Config)
 type RetryOptionProvider func(c *Config)
 
 // WithTraceEndpoint - endpoint to send traces
 func WithTraceEndpoint(endpoint string) OptionProvider {
 	return func(c *Config) {
 		c.TraceEndpoint = endpoint
+		c.overrideEnvEndpoint(envSignalTraces)
 	}
 }
 
@@ -23,6 +29,22 @@ func WithTraceEndpoint(endpoint string) OptionProvider {
 func WithMetricEndpoint(endpoint string) OptionProvider {
 	return func(c *Config) {
 		c.MetricEndpoint = endpoint
+		c.overrideEnvEndpoint(envSignalMetrics)
+	}
+}
+
+// WithLogEndpoint - endpoint to send logs
+func WithLogEndpoint(endpoint string) OptionProvider {
+	return func(c *Config) {
+		c.LogEndpoint = endpoint
+		c.overrideEnvEndpoint(envSignalLogs)
+	}
+}
+
+// WithProtocol - protocol used to export traces and metrics, defaults to gRPC
+func WithProtocol(protocol Protocol) OptionProvider {
+	return func(c *Config) {
+		c.Protocol = protocol
 	}
 }
 
@@ -33,6 +55,75 @@ func WithServiceName(serviceName string) OptionProvider {
 	}
 }
 
+// WithServiceVersion - set the service.version resource attribute
+func WithServiceVersion(version string) OptionProvider {
+	return func(c *Config) {
+		c.serviceVersion = version
+	}
+}
+
+// WithServiceInstanceID - set the service.instance.id resource attribute, otherwise use a random UUID generated at start
+func WithServiceInstanceID(id string) OptionProvider {
+	return func(c *Config) {
+		c.serviceInstanceID = id
+	}
+}
+
+// WithResource - set a resource merged over the detected attributes and OTEL_RESOURCE_ATTRIBUTES
+func WithResource(r *resource.Resource) OptionProvider {
+	return func(c *Config) {
+		c.resource = r
+	}
+}
+
+// WithResourceAttributes - add attributes, like team or region, merged over WithResource
+func WithResourceAttributes(attrs map[string]string) OptionProvider {
+	return func(c *Config) {
+		if c.resourceAttributes == nil {
+			c.resourceAttributes = make(map[string]string, len(attrs))
+		}
+		for k, v := range attrs {
+			c.resourceAttributes[k] = v
+		}
+	}
+}
+
+// WithHostDetector - add the host.name resource attribute
+func WithHostDetector() OptionProvider {
+	return func(c *Config) {
+		c.detectors = append(c.detectors, hostDetector()...)
+	}
+}
+
+// WithProcessDetector - add the process pid, executable and runtime resource attributes
+func WithProcessDetector() OptionProvider {
+	return func(c *Config) {
+		c.detectors = append(c.detectors, processDetector()...)
+	}
+}
+
+// WithOSDetector - add the os.type resource attribute
+func WithOSDetector() OptionProvider {
+	return func(c *Config) {
+		c.detectors = append(c.detectors, osDetector()...)
+	}
+}
+
+// WithContainerDetector - add the container.id resource attribute read from the cgroup files
+func WithContainerDetector() OptionProvider {
+	return func(c *Config) {
+		c.detectors = append(c.detectors, containerDetector()...)
+	}
+}
+
+// WithKubernetesDetector - add the k8s pod, namespace and node resource attributes
+// read from the K8S_POD_NAME, K8S_POD_UID, K8S_NAMESPACE_NAME and K8S_NODE_NAME downward API env vars
+func WithKubernetesDetector() OptionProvider {
+	return func(c *Config) {
+		c.detectors = append(c.detectors, kubernetesDetector()...)
+	}
+}
+
 // WithAppEnv - app environment level
 func WithAppEnv(appEnv EnvLevel) OptionProvider {
 	return func(c *Config) {
@@ -44,6 +135,7 @@ func WithAppEnv(appEnv EnvLevel) OptionProvider {
 func WithHeaders(headers map[string]string) OptionProvider {
 	return func(c *Config) {
 		c.Headers = headers
+		c.overrideSignalEnv(func(e *signalEnv) { e.headers = nil })
 	}
 }
 
@@ -51,6 +143,38 @@ func WithHeaders(headers map[string]string) OptionProvider {
 func WithInsecure(insecure bool) OptionProvider {
 	return func(c *Config) {
 		c.Insecure = insecure
+		c.overrideSignalEnv(func(e *signalEnv) { e.insecure, e.endpointInsecure = nil, false })
+	}
+}
+
+// WithTLSConfig - base TLS configuration for gRPC and HTTP exporters, the value is cloned before use
+func WithTLSConfig(tlsConfig *tls.Config) OptionProvider {
+	return func(c *Config) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// WithCACertFile - PEM file with the CA used to verify the collector certificate, reloaded when the file changes
+func WithCACertFile(path string) OptionProvider {
+	return func(c *Config) {
+		c.CACertFile = path
+		c.overrideSignalEnv(func(e *signalEnv) { e.caCertFile = "" })
+	}
+}
+
+// WithClientCertificate - PEM client certificate and key used for mTLS, reloaded when the files change
+func WithClientCertificate(certFile, keyFile string) OptionProvider {
+	return func(c *Config) {
+		c.ClientCertFile = certFile
+		c.ClientKeyFile = keyFile
+		c.overrideSignalEnv(func(e *signalEnv) { e.clientCertFile, e.clientKeyFile = "", "" })
+	}
+}
+
+// WithTLSServerName - override the server name used to verify the collector certificate
+func WithTLSServerName(serverName string) OptionProvider {
+	return func(c *Config) {
+		c.ServerName = serverName
 	}
 }
 
@@ -65,6 +189,7 @@ func WithGRPCConnectionBlock(block bool) OptionProvider {
 func WithTimeout(timeout time.Duration) OptionProvider {
 	return func(c *Config) {
 		c.Timeout = timeout
+		c.overrideSignalEnv(func(e *signalEnv) { e.timeout = nil })
 	}
 }
 
@@ -86,6 +211,15 @@ func WithTrace(opts ...TraceOptionProvider) OptionProvider {
 	}
 }
 
+// WithLog - log options
+func WithLog(opts ...LogOptionProvider) OptionProvider {
+	return func(c *Config) {
+		for _, o := range opts {
+			o(c)
+		}
+	}
+}
+
 // WithMetricReader - set the reader for metric, otherwise use the default sdkmetric.NewPeriodicReader
 func WithMetricReader(r metric.Reader) MetricOptionProvider {
 	return func(c *Config) {
@@ -100,6 +234,79 @@ func WithMetricViews(v []metric.View) MetricOptionProvider {
 	}
 }
 
+// WithMetricViewRules - add ordered rules renaming, dropping or changing the buckets and the attributes of the
+// matching instruments, the first matching rule applies over the views, see otelpp.ViewRule
+func WithMetricViewRules(rules ...ViewRule) MetricOptionProvider {
+	return func(c *Config) {
+		c.viewRules = append(c.viewRules, rules...)
+	}
+}
+
+// WithMetricProtocol - protocol used to export metrics, overrides WithProtocol
+func WithMetricProtocol(protocol Protocol) MetricOptionProvider {
+	return func(c *Config) {
+		c.MetricProtocol = protocol
+	}
+}
+
+// WithMetricDestination - send a copy of the metrics to an additional collector, configured by opts
+// applied over the provider configuration, e.g. WithMetricEndpoint, WithMetricProtocol, WithHeaders or WithTLSConfig.
+// The headers of the provider are not sent to the destination, name must be unique, see otelpp.ErrInvalidDestinationName
+func WithMetricDestination(name string, opts ...OptionProvider) MetricOptionProvider {
+	return func(c *Config) {
+		c.metricDestinations = append(c.metricDestinations, destination{name: name, opts: opts})
+	}
+}
+
+// WithMetricFailover - send the metrics to a secondary collector, configured by opts applied over the provider
+// configuration, when an export to the metric endpoint fails, and all of them after consecutive failures, failing back
+// when it recovers, see otelpp.FailoverConfig.
+// The headers of the provider are not sent to the secondary collector, set them with WithHeaders in opts
+func WithMetricFailover(cfg FailoverConfig, opts ...OptionProvider) MetricOptionProvider {
+	return func(c *Config) {
+		c.metricFailover = &failover{cfg: cfg, opts: opts}
+	}
+}
+
+// WithTemporalitySelector - temporality of the metrics exported by the OTLP exporters per instrument kind,
+// otherwise cumulative, see otelpp.TemporalityPreference for the presets
+func WithTemporalitySelector(s metric.TemporalitySelector) MetricOptionProvider {
+	return func(c *Config) {
+		c.temporality = s
+	}
+}
+
+// WithTemporalityPreference - temporality preset of the metrics exported by the OTLP exporters: cumulative,
+// delta or lowmemory, same as OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE, an invalid preset keeps cumulative
+func WithTemporalityPreference(p TemporalityPreference) MetricOptionProvider {
+	return WithTemporalitySelector(p.Selector())
+}
+
+// WithAggregationSelector - default aggregation of the metrics exported by the OTLP exporters per instrument
+// kind, otherwise the SDK default, the views set by WithMetricViews take precedence
+func WithAggregationSelector(s metric.AggregationSelector) MetricOptionProvider {
+	return func(c *Config) {
+		c.aggregation = s
+	}
+}
+
+// WithCardinalityLimit - cap the distinct attribute sets per synchronous instrument, recording the excess
+// measurements in the otel.metric.overflow=true series, see otelpp.CardinalityLimitConfig. Only the instruments
+// of the returned Meter are limited, not the ones of the global meter provider, e.g. otelgin, host and runtime
+func WithCardinalityLimit(cfg CardinalityLimitConfig) MetricOptionProvider {
+	return func(c *Config) {
+		c.cardinalityLimit = &cfg
+	}
+}
+
+// WithPrometheus - expose the metrics on a Prometheus pull endpoint, alongside the OTLP exporter when
+// a metric endpoint is set or instead of it otherwise, see otelpp.PrometheusConfig
+func WithPrometheus(cfg PrometheusConfig) MetricOptionProvider {
+	return func(c *Config) {
+		c.prometheus = &cfg
+	}
+}
+
 // WithSendIntervalMetric - set send interval to otel collector
 func WithSendIntervalMetric(si time.Duration) MetricOptionProvider {
 	return func(c *Config) {
@@ -107,6 +314,32 @@ func WithSendIntervalMetric(si time.Duration) MetricOptionProvider {
 	}
 }
 
+// WithTraceProtocol - protocol used to export traces, overrides WithProtocol
+func WithTraceProtocol(protocol Protocol) TraceOptionProvider {
+	return func(c *Config) {
+		c.TraceProtocol = protocol
+	}
+}
+
+// WithTraceDestination - send a copy of the spans to an additional collector, configured by opts
+// applied over the provider configuration, e.g. WithTraceEndpoint, WithTraceProtocol, WithHeaders or WithTLSConfig.
+// The headers of the provider are not sent to the destination, name must be unique, see otelpp.ErrInvalidDestinationName
+func WithTraceDestination(name string, opts ...OptionProvider) TraceOptionProvider {
+	return func(c *Config) {
+		c.traceDestinations = append(c.traceDestinations, destination{name: name, opts: opts})
+	}
+}
+
+// WithTraceFailover - send the spans to a secondary collector, configured by opts applied over the provider
+// configuration, when an export to the trace endpoint fails, and all of them after consecutive failures, failing back
+// when it recovers, see otelpp.FailoverConfig.
+// The headers of the provider are not sent to the secondary collector, set them with WithHeaders in opts
+func WithTraceFailover(cfg FailoverConfig, opts ...OptionProvider) TraceOptionProvider {
+	return func(c *Config) {
+		c.traceFailover = &failover{cfg: cfg, opts: opts}
+	}
+}
+
 // WithSendIntervalTrace - set send interval to otel collector
 func WithSendIntervalTrace(si time.Duration) TraceOptionProvider {
 	return func(c *Config) {
@@ -114,6 +347,62 @@ func WithSendIntervalTrace(si time.Duration) TraceOptionProvider {
 	}
 }
 
+// WithLogProtocol - protocol used to export logs, overrides WithProtocol
+func WithLogProtocol(protocol Protocol) LogOptionProvider {
+	return func(c *Config) {
+		c.LogProtocol = protocol
+	}
+}
+
+// WithSendIntervalLog - set send interval to otel collector
+func WithSendIntervalLog(si time.Duration) LogOptionProvider {
+	return func(c *Config) {
+		c.sendIntervalLog = &si
+	}
+}
+
+// WithSampler - set the sampler for trace, otherwise use OTEL_TRACES_SAMPLER or otelpp.DefaultSampler
+func WithSampler(s sdktrace.Sampler) TraceOptionProvider {
+	return func(c *Config) {
+		c.sampler = s
+	}
+}
+
+// WithSamplingRules - set ordered rules that decide the sampling of the matching root spans, see otelpp.RuleSampler
+func WithSamplingRules(rules ...SamplingRule) TraceOptionProvider {
+	return func(c *Config) {
+		c.samplingRules = append(c.samplingRules, rules...)
+	}
+}
+
+// WithRateLimitingSampler - set a otelpp.RateLimitingSampler capping the sampled root spans per second
+func WithRateLimitingSampler(tracesPerSecond float64) TraceOptionProvider {
+	return WithSampler(NewRateLimitingSampler(tracesPerSecond))
+}
+
+// WithTailSampling - set a otelpp.TailSamplingProcessor in front of the batcher, the default sampler becomes always on
+func WithTailSampling(cfg TailSamplingConfig) TraceOptionProvider {
+	return func(c *Config) {
+		c.tailSampling = &cfg
+	}
+}
+
+// WithSpool - spool the traces and metrics that fail to be exported with a transient error to a bounded
+// disk queue and replay them when the endpoint recovers, the payloads rejected by the endpoint are dropped,
+// see otelpp.SpoolConfig
+func WithSpool(cfg SpoolConfig) OptionProvider {
+	return func(c *Config) {
+		c.spool = &cfg
+	}
+}
+
+// WithPropagators - set the global propagators, otherwise use OTEL_PROPAGATORS or W3C Trace Context and Baggage
+func WithPropagators(p ...propagation.TextMapPropagator) OptionProvider {
+	return func(c *Config) {
+		c.propagators = append([]propagation.TextMapPropagator{}, p...)
+	}
+}
+
 // WithRetryDefault - retry options with default values
 // Recommended at go.opentelemetry.io/otel/exporters/otlp/internal/retry.DefaultConfig
 func WithRetryDefault() OptionProvider {
@@ -128,6 +417,7 @@ func WithRetryDefault() OptionProvider {
 // WithRetry - retry options
 func WithRetry(opts ...RetryOptionProvider) OptionProvider {
 	return func(c *Config) {
+		c.RetryConfig.configured = true
 		for _, opt := range opts {
 			opt(c)
 		}
@@ -173,5 +463,6 @@ func WithLogger(l logr.Logger) OptionProvider {
 func WithGzipCompression(useGzipCompression bool) OptionProvider {
 	return func(c *Config) {
 		c.UseGzipCompression = useGzipCompression
+		c.overrideSignalEnv(func(e *signalEnv) { e.compression = nil })
 	}
 }
@@ -0,0 +1,290 @@
+/*
+Package otelpptest provides an in-memory otelpp.Telemetry and otelpp.Meter
+to unit test the instrumentation, recording the ended spans with a span
+recorder and collecting the metrics on demand with a manual reader.
+
+	h := otelpptest.New()
+	instr := telemetry.New(h.Telemetry(), h.Meter(), nil)
+
+	handle(ctx, instr)
+
+	h.AssertSpan(t, "GET /users", semconv.HTTPMethod("GET"))
+	if c, err := h.FindCounter("requests"); err != nil || c.Total() != 1 {
+		t.Errorf("unexpected requests counter: %v, %v", c, err)
+	}
+
+The global providers are not changed, so only the spans and metrics created
+through the Telemetry and Meter of the Harness are recorded. WithSampler and
+WithViews test the samplers and views of otelpp with the Harness.
+*/
+package otelpptest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	otelpp "otlp-stack/pkg/opentelemetry"
+)
+
+const instrumentationName = "otelpptest"
+
+var (
+	ErrMetricNotFound = errors.New("metric not found")
+	ErrNotCounter     = errors.New("metric is not a counter")
+)
+
+// TestingT is the subset of testing.TB used by the assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Harness records the spans and metrics of its Telemetry and Meter.
+type Harness struct {
+	recorder *tracetest.SpanRecorder
+	reader   sdkmetric.Reader
+	tracing  *otelpp.Tracing
+	metric   *otelpp.Metric
+}
+
+type config struct {
+	sampler sdktrace.Sampler
+	views   []sdkmetric.View
+}
+
+// Option changes the providers of the Harness.
+type Option func(c *config)
+
+// WithSampler - sampler of the spans, default value sdktrace.AlwaysSample
+func WithSampler(s sdktrace.Sampler) Option {
+	return func(c *config) {
+		c.sampler = s
+	}
+}
+
+// WithViews - views of the metrics, replacing the default views of otelpp
+func WithViews(views ...sdkmetric.View) Option {
+	return func(c *config) {
+		c.views = views
+	}
+}
+
+// New returns a Harness sampling every span and using the default views of
+// otelpp, unless changed by opts.
+func New(opts ...Option) *Harness {
+	cfg := config{
+		sampler: sdktrace.AlwaysSample(),
+		views:   otelpp.NewMetricHistogramBucketView(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(cfg.sampler),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithView(cfg.views...),
+	)
+
+	return &Harness{
+		recorder: recorder,
+		reader:   reader,
+		tracing:  otelpp.NewTracing(tp, instrumentationName),
+		metric:   otelpp.NewMetric(mp, instrumentationName),
+	}
+}
+
+// Telemetry returns the Telemetry recording the spans.
+func (h *Harness) Telemetry() otelpp.Telemetry {
+	return h.tracing
+}
+
+// Meter returns the Meter whose metrics are collected by CollectMetrics.
+func (h *Harness) Meter() otelpp.Meter {
+	return h.metric
+}
+
+// Shutdown shuts down the providers of the Telemetry and Meter.
+func (h *Harness) Shutdown(ctx context.Context) error {
+	if err := h.tracing.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	return h.metric.Shutdown(ctx)
+}
+
+// Spans returns the ended spans, in the order they ended.
+func (h *Harness) Spans() []sdktrace.ReadOnlySpan {
+	return h.recorder.Ended()
+}
+
+// FindSpan returns the first ended span named name having all the attrs.
+func (h *Harness) FindSpan(name string, attrs ...attribute.KeyValue) (sdktrace.ReadOnlySpan, bool) {
+	for _, s := range h.Spans() {
+		if s.Name() == name && hasAttributes(s.Attributes(), attrs) {
+			return s, true
+		}
+	}
+
+	return nil, false
+}
+
+// AssertSpan reports an error to t when no ended span is named name with all
+// the attrs, and returns the matching span otherwise.
+func (h *Harness) AssertSpan(t TestingT, name string, attrs ...attribute.KeyValue) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	s, ok := h.FindSpan(name, attrs...)
+	if !ok {
+		t.Errorf("span %q with attributes %v not found, ended spans: %v", name, attrs, spanNames(h.Spans()))
+	}
+
+	return s
+}
+
+// CollectMetrics collects the metrics recorded since the creation of the
+// Harness, the values are cumulative.
+func (h *Harness) CollectMetrics() (metricdata.ResourceMetrics, error) {
+	var rm metricdata.ResourceMetrics
+	err := h.reader.Collect(context.Background(), &rm)
+	return rm, err
+}
+
+// FindMetric collects the metrics and returns the metric named name, or
+// ErrMetricNotFound or the error of the collection.
+func (h *Harness) FindMetric(name string) (metricdata.Metrics, error) {
+	rm, err := h.CollectMetrics()
+	if err != nil {
+		return metricdata.Metrics{}, err
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m, nil
+			}
+		}
+	}
+
+	return metricdata.Metrics{}, fmt.Errorf("%w: %q", ErrMetricNotFound, name)
+}
+
+// FindCounter collects the metrics and returns the counter or up down
+// counter named name, synchronous or observable, or ErrNotCounter when the
+// metric has another type.
+func (h *Harness) FindCounter(name string) (Counter, error) {
+	m, err := h.FindMetric(name)
+	if err != nil {
+		return Counter{}, err
+	}
+
+	c := Counter{Name: m.Name}
+	switch data := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		for _, dp := range data.DataPoints {
+			c.DataPoints = append(c.DataPoints, CounterPoint{Attributes: dp.Attributes, Value: float64(dp.Value)})
+		}
+	case metricdata.Sum[float64]:
+		for _, dp := range data.DataPoints {
+			c.DataPoints = append(c.DataPoints, CounterPoint{Attributes: dp.Attributes, Value: dp.Value})
+		}
+	default:
+		return Counter{}, fmt.Errorf("%w: %q is a %T", ErrNotCounter, name, m.Data)
+	}
+
+	return c, nil
+}
+
+// AssertCounter reports an error to t when the counter named name has not
+// the value for the attrs.
+func (h *Harness) AssertCounter(t TestingT, name string, value float64, attrs ...attribute.KeyValue) {
+	t.Helper()
+
+	c, err := h.FindCounter(name)
+	if err != nil {
+		t.Errorf("counter %q: %v", name, err)
+		return
+	}
+
+	if got := c.Value(attrs...); got != value {
+		t.Errorf("counter %q with attributes %v: got %v, want %v", name, attrs, got, value)
+	}
+}
+
+// Counter is a collected counter, the int64 values are converted to float64.
+type Counter struct {
+	Name       string
+	DataPoints []CounterPoint
+}
+
+// CounterPoint is the value of a Counter for a set of attributes.
+type CounterPoint struct {
+	Attributes attribute.Set
+	Value      float64
+}
+
+// Value returns the value of the data point with exactly the attrs, 0 when
+// there is none.
+func (c Counter) Value(attrs ...attribute.KeyValue) float64 {
+	set := attribute.NewSet(attrs...)
+	for _, dp := range c.DataPoints {
+		if dp.Attributes.Equals(&set) {
+			return dp.Value
+		}
+	}
+
+	return 0
+}
+
+// Total returns the sum of the values of all the data points.
+func (c Counter) Total() float64 {
+	var total float64
+	for _, dp := range c.DataPoints {
+		total += dp.Value
+	}
+
+	return total
+}
+
+func (c Counter) String() string {
+	var b strings.Builder
+	b.WriteString(c.Name)
+	for _, dp := range c.DataPoints {
+		fmt.Fprintf(&b, " {%s}=%v", dp.Attributes.Encoded(attribute.DefaultEncoder()), dp.Value)
+	}
+
+	return b.String()
+}
+
+func hasAttributes(got []attribute.KeyValue, want []attribute.KeyValue) bool {
+	set := attribute.NewSet(got...)
+	for _, kv := range want {
+		if v, ok := set.Value(kv.Key); !ok || v != kv.Value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+
+	return names
+}
@@ -0,0 +1,160 @@
+package otelpptest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"otlp-stack/pkg/opentelemetry/otelpptest"
+)
+
+// recordingT records the errors reported by the assertions.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestHarness_AssertSpan(t *testing.T) {
+	h := otelpptest.New()
+	ctx := context.Background()
+
+	_, span := h.Telemetry().Start(ctx, "GET /users")
+	span.SetAttributes(attribute.String("http.method", "GET"), attribute.Int("http.status_code", 200))
+	span.End()
+
+	rt := &recordingT{}
+	s := h.AssertSpan(rt, "GET /users", attribute.String("http.method", "GET"))
+	if len(rt.errors) != 0 || s == nil || s.Name() != "GET /users" {
+		t.Errorf("AssertSpan of an ended span: span %v, errors %v", s, rt.errors)
+	}
+
+	tests := map[string][]attribute.KeyValue{
+		"other value":       {attribute.String("http.method", "POST")},
+		"missing attribute": {attribute.String("http.route", "/users")},
+		"one of two":        {attribute.String("http.method", "GET"), attribute.Int("http.status_code", 500)},
+	}
+	for name, attrs := range tests {
+		t.Run(name, func(t *testing.T) {
+			rt := &recordingT{}
+			if s := h.AssertSpan(rt, "GET /users", attrs...); s != nil || len(rt.errors) != 1 {
+				t.Errorf("AssertSpan: span %v, errors %v", s, rt.errors)
+			}
+		})
+	}
+
+	rt = &recordingT{}
+	h.AssertSpan(rt, "GET /orders")
+	if len(rt.errors) != 1 {
+		t.Fatalf("AssertSpan of an unknown span: errors %v", rt.errors)
+	}
+	if want := "ended spans: [GET /users]"; !strings.Contains(rt.errors[0], want) {
+		t.Errorf("error %q does not list the ended spans %q", rt.errors[0], want)
+	}
+}
+
+func TestHarness_FindSpanIgnoresUnendedSpans(t *testing.T) {
+	h := otelpptest.New()
+
+	_, span := h.Telemetry().Start(context.Background(), "running")
+	if _, ok := h.FindSpan("running"); ok {
+		t.Error("found a span not ended")
+	}
+
+	span.End()
+	if _, ok := h.FindSpan("running"); !ok {
+		t.Error("ended span not found")
+	}
+}
+
+func TestHarness_FindCounter(t *testing.T) {
+	h := otelpptest.New()
+	ctx := context.Background()
+
+	requests, err := h.Meter().Int64Counter("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests.Add(ctx, 1, attribute.String("route", "/a"))
+	requests.Add(ctx, 2, attribute.String("route", "/b"))
+	requests.Add(ctx, 3, attribute.String("route", "/b"))
+
+	inflight, err := h.Meter().Float64UpDownCounter("inflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inflight.Add(ctx, 1.5)
+	inflight.Add(ctx, -0.5)
+
+	c, err := h.FindCounter("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := []struct {
+		attrs []attribute.KeyValue
+		want  float64
+	}{
+		{[]attribute.KeyValue{attribute.String("route", "/a")}, 1},
+		{[]attribute.KeyValue{attribute.String("route", "/b")}, 5},
+		{nil, 0},
+		{[]attribute.KeyValue{attribute.String("route", "/a"), attribute.String("method", "GET")}, 0},
+	}
+	for _, v := range values {
+		if got := c.Value(v.attrs...); got != v.want {
+			t.Errorf("Value(%v) = %v, want %v", v.attrs, got, v.want)
+		}
+	}
+	if got := c.Total(); got != 6 {
+		t.Errorf("Total() = %v, want 6", got)
+	}
+
+	up, err := h.FindCounter("inflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := up.Value(); got != 1 {
+		t.Errorf("up down counter Value() = %v, want 1", got)
+	}
+
+	rt := &recordingT{}
+	h.AssertCounter(rt, "requests", 5, attribute.String("route", "/b"))
+	h.AssertCounter(rt, "requests", 2, attribute.String("route", "/a"))
+	if len(rt.errors) != 1 {
+		t.Errorf("AssertCounter errors %v, want the one of the wrong value", rt.errors)
+	}
+}
+
+func TestHarness_FindCounterErrors(t *testing.T) {
+	h := otelpptest.New()
+
+	latency, err := h.Meter().Float64Histogram("latency")
+	if err != nil {
+		t.Fatal(err)
+	}
+	latency.Record(context.Background(), 12)
+
+	if _, err = h.FindCounter("latency"); !errors.Is(err, otelpptest.ErrNotCounter) {
+		t.Errorf("FindCounter of a histogram: %v, want ErrNotCounter", err)
+	}
+
+	if _, err = h.FindCounter("missing"); !errors.Is(err, otelpptest.ErrMetricNotFound) {
+		t.Errorf("FindCounter of a missing metric: %v, want ErrMetricNotFound", err)
+	}
+
+	if err = h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the collection fails once the reader is shut down
+	if _, err = h.FindMetric("latency"); err == nil || errors.Is(err, otelpptest.ErrMetricNotFound) {
+		t.Errorf("FindMetric after Shutdown: %v, want the collection error", err)
+	}
+}
@@ -0,0 +1,136 @@
+package otelpp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultTracesPath   = "/v1/traces"
+	defaultMetricsPath  = "/v1/metrics"
+	defaultLogsPath     = "/v1/logs"
+	contentTypeJSON     = "application/json"
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+// otlpHTTPClient sends OTLP requests over HTTP for the payloads the SDK
+// exporters of this version do not support: JSON encoding and logs.
+type otlpHTTPClient struct {
+	url     string
+	headers map[string]string
+	gzip    bool
+	json    bool
+	retry   RetryConfig
+	client  *http.Client
+}
+
+func newOtlpHTTPClient(cfg Config, endpoint, path string, json bool) (*otlpHTTPClient, error) {
+	scheme := "https"
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.Insecure {
+		scheme = "http"
+	} else if cfg.tlsEnable() {
+		tlsCfg, err := createTLSConfig(cfg, endpoint)
+		if err != nil {
+			return nil, errors.Wrap(err, err.Error())
+		}
+		transport.TLSClientConfig = tlsCfg
+	}
+
+	timeout := 10 * time.Second
+	if cfg.ValidTimeout() {
+		timeout = cfg.Timeout
+	}
+
+	return &otlpHTTPClient{
+		url:     fmt.Sprintf("%s://%s%s", scheme, trimEndpoint(endpoint), path),
+		headers: cfg.Headers,
+		gzip:    cfg.UseGzipCompression,
+		json:    json,
+		retry:   cfg.RetryConfig,
+		client:  &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+func (c *otlpHTTPClient) send(ctx context.Context, msg proto.Message) error {
+	body, err := c.marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	if c.gzip {
+		var buf bytes.Buffer
+		gz, _ := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+		if _, err = gz.Write(body); err != nil {
+			return errors.Wrap(err, err.Error())
+		}
+		if err = gz.Close(); err != nil {
+			return errors.Wrap(err, err.Error())
+		}
+		body = buf.Bytes()
+	}
+
+	return c.retry.retry(ctx, func(ctx context.Context) error {
+		return c.post(ctx, body)
+	})
+}
+
+// post sends the encoded body once, the responses 429 and 503 are retried
+// after the delay of their Retry-After header.
+func (c *otlpHTTPClient) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+	req.Header.Set("Content-Type", c.contentType())
+	if c.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	statusErr := &httpStatusError{url: c.url, status: resp.Status, code: resp.StatusCode}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		return &retryableError{
+			err:      statusErr,
+			throttle: retryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	return statusErr
+}
+
+func (c *otlpHTTPClient) marshal(msg proto.Message) ([]byte, error) {
+	if c.json {
+		return MarshalJSON(msg)
+	}
+	return proto.Marshal(msg)
+}
+
+func (c *otlpHTTPClient) contentType() string {
+	if c.json {
+		return contentTypeJSON
+	}
+	return contentTypeProtobuf
+}
@@ -0,0 +1,171 @@
+package otelpp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+const (
+	defaultPrometheusPath = "/metrics"
+	targetInfoName        = "target_info"
+)
+
+/*
+PrometheusConfig - configuration of the Prometheus pull endpoint set by WithPrometheus, exposing the
+metrics in the text and OpenMetrics formats, alongside the OTLP exporter when a metric endpoint is set
+or instead of it otherwise.
+
+ListenAddress - address of the standalone HTTP server serving the endpoint, e.g. ":9464", not started
+when empty, the endpoint is then served by the application with Metric.PrometheusHandler
+Path - path of the standalone HTTP server, default /metrics
+ResourceToTelemetryConversion - add the resource attributes as labels of every series, like the
+resource_to_telemetry_conversion of the Prometheus exporter of the collector, target_info is kept
+WithoutUnits - do not add the unit suffix to the metric names
+WithoutScopeInfo - do not expose otel_scope_info nor the otel_scope_* labels
+
+The exemplars are not exposed, the metric SDK v0.37 does not record them.
+*/
+type PrometheusConfig struct {
+	ListenAddress                 string
+	Path                          string
+	ResourceToTelemetryConversion bool
+	WithoutUnits                  bool
+	WithoutScopeInfo              bool
+}
+
+// prometheusEndpoint is the Prometheus endpoint of a metric provider.
+type prometheusEndpoint struct {
+	handler http.Handler
+	server  *http.Server
+}
+
+// newPrometheusReader returns the reader collecting the metrics when the
+// endpoint is scraped, and the endpoint serving them.
+func newPrometheusReader(cfg Config) (sdkmetric.Reader, *prometheusEndpoint, error) {
+	pc := cfg.prometheus
+	registry := prometheus.NewRegistry()
+
+	opts := []otelprom.Option{otelprom.WithRegisterer(registry)}
+	if pc.WithoutUnits {
+		opts = append(opts, otelprom.WithoutUnits())
+	}
+	if pc.WithoutScopeInfo {
+		opts = append(opts, otelprom.WithoutScopeInfo())
+	}
+
+	exp, err := otelprom.New(opts...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, err.Error())
+	}
+
+	var gatherer prometheus.Gatherer = registry
+	if pc.ResourceToTelemetryConversion {
+		gatherer = resourceLabelsGatherer{registry}
+	}
+
+	return exp, &prometheusEndpoint{
+		handler: promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+			EnableOpenMetrics: true,
+			ErrorHandling:     promhttp.ContinueOnError,
+		}),
+	}, nil
+}
+
+// start serves the endpoint on the ListenAddress of cfg, when set. The
+// address is listened on before returning, to report the errors like an
+// address already in use.
+func (e *prometheusEndpoint) start(cfg Config) error {
+	pc := cfg.prometheus
+	if pc.ListenAddress == "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", pc.ListenAddress)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(signalPath(pc.Path, defaultPrometheusPath), e.handler)
+	e.server = &http.Server{Handler: mux}
+
+	go func() {
+		if err := e.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			cfg.Logger.Error(err, "prometheus endpoint stopped")
+		}
+	}()
+
+	return nil
+}
+
+// shutdown stops the standalone HTTP server, if started.
+func (e *prometheusEndpoint) shutdown(ctx context.Context) error {
+	if e == nil || e.server == nil {
+		return nil
+	}
+
+	return e.server.Shutdown(ctx)
+}
+
+// resourceLabelsGatherer adds the labels of target_info, the resource
+// attributes, to the series of the other metrics.
+type resourceLabelsGatherer struct {
+	prometheus.Gatherer
+}
+
+func (g resourceLabelsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.Gatherer.Gather()
+
+	var resourceLabels []*dto.LabelPair
+	for _, mf := range mfs {
+		if mf.GetName() == targetInfoName && len(mf.Metric) > 0 {
+			resourceLabels = mf.Metric[0].Label
+		}
+	}
+
+	if len(resourceLabels) == 0 {
+		return mfs, err
+	}
+
+	for _, mf := range mfs {
+		if mf.GetName() == targetInfoName {
+			continue
+		}
+
+		for _, m := range mf.Metric {
+			m.Label = appendMissingLabels(m.Label, resourceLabels)
+		}
+	}
+
+	return mfs, err
+}
+
+// appendMissingLabels appends the labels whose name is not in dst, keeping
+// the labels sorted by name as gathered by the registry.
+func appendMissingLabels(dst, labels []*dto.LabelPair) []*dto.LabelPair {
+	names := make(map[string]bool, len(dst))
+	for _, l := range dst {
+		names[l.GetName()] = true
+	}
+
+	for _, l := range labels {
+		if !names[l.GetName()] {
+			dst = append(dst, l)
+		}
+	}
+
+	sort.Slice(dst, func(i, j int) bool {
+		return dst[i].GetName() < dst[j].GetName()
+	})
+
+	return dst
+}
@@ -0,0 +1,144 @@
+package otelpp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestPrometheus_Scrape(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   []string
+	}{
+		{
+			name: "text",
+			want: []string{
+				`# TYPE requests_total counter`,
+				`requests_total{otel_scope_name="test",otel_scope_version="",route="/users"} 3`,
+				`target_info{deployment_environment="dev",service_instance_id="i-1",service_name="test"} 1`,
+			},
+		},
+		{
+			name:   "openmetrics",
+			accept: "application/openmetrics-text; version=0.0.1",
+			want: []string{
+				`# TYPE requests counter`,
+				`requests_total{otel_scope_name="test",otel_scope_version="",route="/users"} 3.0`,
+				`# EOF`,
+			},
+		},
+	}
+
+	m := newTestPrometheusMetric(t, PrometheusConfig{})
+	handler := m.PrometheusHandler()
+	if handler == nil {
+		t.Fatal("PrometheusHandler = nil, want the handler of the endpoint")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assertScrape(t, w.Code, w.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestPrometheus_ResourceToTelemetryConversion(t *testing.T) {
+	m := newTestPrometheusMetric(t, PrometheusConfig{ResourceToTelemetryConversion: true, WithoutScopeInfo: true})
+
+	w := httptest.NewRecorder()
+	m.PrometheusHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// the resource labels are merged in order, target_info is kept
+	assertScrape(t, w.Code, w.Body.String(), []string{
+		`requests_total{deployment_environment="dev",route="/users",service_instance_id="i-1",service_name="test"} 3`,
+		`target_info{deployment_environment="dev",service_instance_id="i-1",service_name="test"} 1`,
+	})
+}
+
+func TestPrometheus_Server(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	newTestPrometheusMetric(t, PrometheusConfig{ListenAddress: addr, Path: "/custom"})
+
+	resp, err := http.Get("http://" + addr + "/custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertScrape(t, resp.StatusCode, string(body), []string{`requests_total{otel_scope_name="test",otel_scope_version="",route="/users"} 3`})
+
+	// the address in use is reported when the provider is created
+	cfg := buildConfig(WithServiceName("test"), WithMetric(WithPrometheus(PrometheusConfig{ListenAddress: addr})))
+	if _, err = newMetricProvider(context.Background(), cfg); err == nil {
+		t.Error("newMetricProvider on an address in use: nil error")
+	}
+}
+
+func TestMetric_PrometheusHandlerUnset(t *testing.T) {
+	m := &Metric{}
+	if m.PrometheusHandler() != nil {
+		t.Error("PrometheusHandler without WithPrometheus, want nil")
+	}
+}
+
+// newTestPrometheusMetric returns the metric provider exposing pc, with
+// the requests counter of the route /users at 3.
+func newTestPrometheusMetric(t *testing.T, pc PrometheusConfig) *Metric {
+	t.Helper()
+	clearOtelEnv(t)
+
+	cfg := buildConfig(WithServiceName("test"), WithAppEnv(DEV), WithServiceInstanceID("i-1"), WithMetric(WithPrometheus(pc)))
+	m, err := newMetricProvider(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = m.Shutdown(context.Background())
+	})
+
+	counter, err := m.provider.Meter("test").Int64Counter("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Add(context.Background(), 3, attribute.String("route", "/users"))
+
+	return m
+}
+
+func assertScrape(t *testing.T, code int, body string, want []string) {
+	t.Helper()
+
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, body:\n%s", code, body)
+	}
+	for _, line := range want {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, body)
+		}
+	}
+}
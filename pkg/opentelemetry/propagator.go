@@ -0,0 +1,99 @@
+package otelpp
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const envPropagators = "OTEL_PROPAGATORS"
+
+// Propagator names accepted by PropagatorsFromString and OTEL_PROPAGATORS.
+const (
+	PropagatorTraceContext = "tracecontext"
+	PropagatorBaggage      = "baggage"
+	PropagatorB3           = "b3"
+	PropagatorB3Multi      = "b3multi"
+	PropagatorJaeger       = "jaeger"
+	PropagatorNone         = "none"
+)
+
+/*
+PropagatorsFromString translates a comma separated list of propagator
+names, as defined for OTEL_PROPAGATORS, to the propagators:
+
+  - tracecontext: W3C Trace Context traceparent and tracestate headers
+  - baggage: W3C Baggage header
+  - b3: B3 single b3 header
+  - b3multi: B3 multiple X-B3-* headers
+  - jaeger: Jaeger uber-trace-id and uberctx-* headers
+  - none: no propagation, it can't be combined with other names
+
+The names are case-insensitive and the duplicates are ignored.
+*/
+func PropagatorsFromString(names string) ([]propagation.TextMapPropagator, error) {
+	propagators := []propagation.TextMapPropagator{}
+	seen := make(map[string]bool)
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if name == PropagatorNone {
+			continue
+		}
+
+		p, err := propagatorFromString(name)
+		if err != nil {
+			return nil, err
+		}
+		propagators = append(propagators, p)
+	}
+
+	if seen[PropagatorNone] && len(propagators) > 0 {
+		return nil, fmt.Errorf("propagator %q can't be combined with other propagators", PropagatorNone)
+	}
+
+	return propagators, nil
+}
+
+func propagatorFromString(name string) (propagation.TextMapPropagator, error) {
+	switch name {
+	case PropagatorTraceContext:
+		return propagation.TraceContext{}, nil
+	case PropagatorBaggage:
+		return propagation.Baggage{}, nil
+	case PropagatorB3:
+		return b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)), nil
+	case PropagatorB3Multi:
+		return b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)), nil
+	case PropagatorJaeger:
+		return jaeger.Jaeger{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported propagator: %q", name)
+	}
+}
+
+// createPropagator returns the composite of the propagators set with
+// WithPropagators or OTEL_PROPAGATORS, W3C Trace Context and Baggage when
+// none is set.
+func createPropagator(cfg Config) propagation.TextMapPropagator {
+	if cfg.propagators == nil {
+		return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+
+	return propagation.NewCompositeTextMapPropagator(cfg.propagators...)
+}
+
+// setPropagator sets the global propagator, used by the instrumentation
+// libraries to read and write the trace context of the requests.
+func setPropagator(cfg Config) {
+	otel.SetTextMapPropagator(createPropagator(cfg))
+}
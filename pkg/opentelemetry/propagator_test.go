@@ -0,0 +1,85 @@
+package otelpp
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCreatePropagator(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		opts    []OptionProvider
+		headers string
+	}{
+		{name: "default", headers: "baggage,traceparent"},
+		{name: "b3 single", env: "b3", headers: "b3"},
+		{name: "b3 multi", env: "B3Multi", headers: "x-b3-sampled,x-b3-spanid,x-b3-traceid"},
+		{name: "jaeger and baggage", env: "jaeger, baggage, jaeger", headers: "baggage,uber-trace-id"},
+		{name: "none", env: "none", headers: ""},
+		{name: "invalid env ignored", env: "xray", headers: "baggage,traceparent"},
+		{name: "none combined ignored", env: "none,b3", headers: "baggage,traceparent"},
+		{
+			name:    "option over the env",
+			env:     "jaeger",
+			opts:    []OptionProvider{WithPropagators(b3.New())},
+			headers: "b3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOtelEnv(t)
+			if tt.env != "" {
+				t.Setenv(envPropagators, tt.env)
+			}
+
+			p := createPropagator(buildConfig(tt.opts...))
+
+			carrier := propagation.MapCarrier{}
+			p.Inject(testPropagationContext(t), carrier)
+
+			keys := carrier.Keys()
+			sort.Strings(keys)
+			if got := strings.Join(keys, ","); got != tt.headers {
+				t.Errorf("headers = %s, want %s", got, tt.headers)
+			}
+		})
+	}
+}
+
+func TestPropagatorsFromString_Errors(t *testing.T) {
+	for _, names := range []string{"tracecontext,xray", "none,baggage"} {
+		if _, err := PropagatorsFromString(names); err == nil {
+			t.Errorf("PropagatorsFromString(%q): nil error", names)
+		}
+	}
+}
+
+func testPropagationContext(t *testing.T) context.Context {
+	t.Helper()
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	member, err := baggage.NewMember("tenant", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bag, err := baggage.New(member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return baggage.ContextWithBaggage(trace.ContextWithSpanContext(context.Background(), sc), bag)
+}
@@ -0,0 +1,104 @@
+package otelpp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Protocol is the transport used to export a signal.
+type Protocol string
+
+const (
+	ProtocolGRPC         Protocol = "grpc"
+	ProtocolHTTPProtobuf Protocol = "http/protobuf"
+	ProtocolHTTPJSON     Protocol = "http/json"
+	ProtocolJaeger       Protocol = "jaeger"
+)
+
+var (
+	ErrInvalidProtocol       = errors.New("invalid protocol, use one of: grpc, http/protobuf, http/json, jaeger")
+	ErrInvalidMetricProtocol = errors.New("invalid metric protocol, use one of: grpc, http/protobuf, http/json")
+	ErrInvalidLogProtocol    = errors.New("invalid log protocol, use one of: grpc, http/protobuf, http/json")
+)
+
+// ProtocolFromString translates a string to a Protocol, it is case-insensitive
+func ProtocolFromString(protocol string) (Protocol, error) {
+	p := Protocol(strings.ToLower(strings.TrimSpace(protocol)))
+	if !p.valid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidProtocol, protocol)
+	}
+	return p, nil
+}
+
+func (p Protocol) valid() bool {
+	switch p {
+	case ProtocolGRPC, ProtocolHTTPProtobuf, ProtocolHTTPJSON, ProtocolJaeger:
+		return true
+	}
+	return false
+}
+
+// String used to translate a Protocol to string
+func (p Protocol) String() string {
+	return string(p)
+}
+
+// traceProtocol returns the protocol used by traces, the shared protocol
+// or gRPC when not set
+func (c *Config) traceProtocol() Protocol {
+	if c.TraceProtocol != "" {
+		return c.TraceProtocol
+	}
+	if c.Protocol != "" {
+		return c.Protocol
+	}
+	return ProtocolGRPC
+}
+
+// metricProtocol returns the protocol used by metrics, the shared protocol
+// or gRPC when not set
+func (c *Config) metricProtocol() Protocol {
+	if c.MetricProtocol != "" {
+		return c.MetricProtocol
+	}
+	if c.Protocol != "" {
+		return c.Protocol
+	}
+	return ProtocolGRPC
+}
+
+// logProtocol returns the protocol used by logs, the shared protocol
+// or gRPC when not set
+func (c *Config) logProtocol() Protocol {
+	if c.LogProtocol != "" {
+		return c.LogProtocol
+	}
+	if c.Protocol != "" {
+		return c.Protocol
+	}
+	return ProtocolGRPC
+}
+
+// forceProtocol overrides every protocol option, used by the constructors
+// bound to a single transport
+func forceProtocol(protocol Protocol) OptionProvider {
+	return func(c *Config) {
+		c.Protocol = protocol
+		c.TraceProtocol = protocol
+		c.MetricProtocol = protocol
+		c.LogProtocol = protocol
+	}
+}
+
+func validateProtocols(cfg Config) error {
+	if p := cfg.traceProtocol(); cfg.traceEnable() && !p.valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidProtocol, p)
+	}
+
+	if p := cfg.metricProtocol(); cfg.metricEnable() && (!p.valid() || p == ProtocolJaeger) {
+		return fmt.Errorf("%w: %q", ErrInvalidMetricProtocol, p)
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package otelpp
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/metric/global"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+/*
+NewProvider creates and sets the global trace and metric provider
+configured with the OTel Exporters selected by WithProtocol, WithTraceProtocol
+and WithMetricProtocol. gRPC is used when no protocol is set.
+
+The returned Tracing and Metric structure can be used to create new spans or shutdown
+the processor.
+*/
+func NewProvider(ctx context.Context, opts ...OptionProvider) (tracing Telemetry, metric Meter, err error) {
+	cfg := buildConfig(opts...)
+
+	if hasMissingConfigInfo(cfg) {
+		return nil, nil, ErrMissingConfig
+	}
+
+	if err = validateProtocols(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	setErrorHandler(cfg)
+
+	var (
+		t  *Tracing
+		mt *Metric
+	)
+
+	if cfg.traceEnable() {
+		t, err = newTracerProvider(ctx, cfg)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, err.Error())
+		}
+		tracing = t
+	}
+
+	if cfg.metricEnable() {
+		mt, err = newMetricProvider(ctx, cfg)
+		if err != nil {
+			shutdownProviders(ctx, t, nil)
+			return nil, nil, errors.Wrap(err, err.Error())
+		}
+		metric = mt
+
+		if err = registerComponentMetrics(cfg, t, mt); err != nil {
+			shutdownProviders(ctx, t, mt)
+			return nil, nil, errors.Wrap(err, err.Error())
+		}
+	}
+
+	return
+}
+
+// shutdownProviders stops the exporters of the providers created before
+// NewProvider failed, so that they are not leaked.
+func shutdownProviders(ctx context.Context, t *Tracing, m *Metric) {
+	if t != nil {
+		_ = t.Shutdown(ctx)
+	}
+	if m != nil {
+		_ = m.Shutdown(ctx)
+	}
+}
+
+// metricsRegisterer is implemented by the components configured through
+// options that expose their own metrics, like RateLimitingSampler,
+// TailSamplingProcessor and the spooling and failover exporters.
+type metricsRegisterer interface {
+	RegisterMetrics(m Meter) error
+}
+
+// componentWrapper is implemented by the components wrapping other
+// components, whose metrics are registered too.
+type componentWrapper interface {
+	wrapped() []interface{}
+}
+
+// registerComponentMetrics registers the metrics of the configured
+// components on the Meter of the provider.
+func registerComponentMetrics(cfg Config, t *Tracing, m *Metric) error {
+	components := []interface{}{
+		cfg.sampler,
+	}
+
+	if t != nil {
+		components = append(components, t.components...)
+	}
+	components = append(components, m.components...)
+
+	for i := 0; i < len(components); i++ {
+		c := components[i]
+		if w, ok := c.(componentWrapper); ok {
+			components = append(components, w.wrapped()...)
+		}
+		if r, ok := c.(metricsRegisterer); ok {
+			if err := r.RegisterMetrics(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// toComponents returns the elements of s as components that may expose
+// their own metrics.
+func toComponents[T any](s []T) []interface{} {
+	components := make([]interface{}, 0, len(s))
+	for _, c := range s {
+		components = append(components, c)
+	}
+	return components
+}
+
+/*
+newTracerProvider creates and sets the global trace provider
+configured with an OTel Exporter that exports the collected spans
+using the trace protocol.
+
+The returned Tracing structure can be used to create new spans or shutdown
+the span processor.
+*/
+func newTracerProvider(ctx context.Context, cfg Config) (*Tracing, error) {
+	cfg = cfg.signalConfig(envSignalTraces)
+
+	res, err := createResource(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	bsp, exps, err := newTraceSpanProcessor(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	sp, err := createSpanProcessor(bsp, cfg)
+	if err != nil {
+		_ = bsp.Shutdown(ctx)
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	tp, err := createTracerProvider(sp, res, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	otel.SetTracerProvider(tp)
+	setPropagator(cfg)
+	tracer := tp.Tracer(cfg.ServiceName)
+
+	return &Tracing{
+		provider:   tp,
+		tracer:     tracer,
+		components: append([]interface{}{sp}, toComponents(exps)...),
+	}, nil
+}
+
+/*
+newMetricProvider creates and sets the global metric provider
+configured with an OTel Exporter that exports the collected data
+using the metric protocol, and with the Prometheus endpoint when
+WithPrometheus is set.
+
+The returned Metric structure can be used to create or shutdown
+the processor.
+*/
+func newMetricProvider(ctx context.Context, cfg Config) (*Metric, error) {
+	cfg = cfg.signalConfig(envSignalMetrics)
+
+	res, err := createResource(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	readers, exps, err := newMetricReaders(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	var prom *prometheusEndpoint
+	if cfg.prometheus != nil {
+		var r sdkmetric.Reader
+		r, prom, err = newPrometheusReader(cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, err.Error())
+		}
+		readers = append(readers, r)
+	}
+
+	mp, err := createMetricProvider(res, readers, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	if prom != nil {
+		if err = prom.start(cfg); err != nil {
+			_ = mp.Shutdown(ctx)
+			return nil, errors.Wrap(err, err.Error())
+		}
+	}
+
+	global.SetMeterProvider(mp)
+	meter := mp.Meter(cfg.ServiceName)
+
+	m := &Metric{
+		provider:   mp,
+		meter:      meter,
+		components: toComponents(exps),
+		prometheus: prom,
+	}
+
+	if cfg.cardinalityLimit != nil {
+		m.cardinality = newCardinalityLimiter(cfg)
+		m.components = append(m.components, m.cardinality)
+	}
+
+	return m, nil
+}
+
+// newTraceExporter returns the exporter of the trace protocol, failing over
+// to the secondary endpoint when WithTraceFailover is set and spooling the
+// failed exports to disk when WithSpool is set. Jaeger is never failed over
+// nor spooled.
+func newTraceExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	if cfg.traceProtocol() == ProtocolJaeger {
+		return jaegerTraceExporter(cfg)
+	}
+
+	client, err := newProtocolTraceClient(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	if cfg.traceFailover != nil {
+		client, err = newFailoverTraceClient(ctx, client, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, err.Error())
+		}
+	}
+
+	if cfg.spool != nil {
+		client, err = newSpoolTraceClient(client, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, err.Error())
+		}
+	}
+
+	exp, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	return &traceExporter{SpanExporter: exp, client: client}, nil
+}
+
+func newProtocolTraceClient(ctx context.Context, cfg Config) (otlptrace.Client, error) {
+	switch cfg.traceProtocol() {
+	case ProtocolGRPC:
+		return grpcTraceClient(ctx, cfg)
+	case ProtocolHTTPProtobuf:
+		return httpTraceClient(cfg)
+	case ProtocolHTTPJSON:
+		return httpJSONTraceClient(cfg)
+	default:
+		return nil, ErrInvalidProtocol
+	}
+}
+
+// newMetricExporter returns the exporter of the metric protocol, failing
+// over to the secondary endpoint when WithMetricFailover is set and spooling
+// the failed exports to disk when WithSpool is set.
+func newMetricExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	exp, err := newProtocolMetricExporter(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	if cfg.metricFailover != nil {
+		exp, err = newFailoverMetricExporter(ctx, exp, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, err.Error())
+		}
+	}
+
+	if cfg.spool != nil {
+		return newSpoolMetricExporter(exp, cfg)
+	}
+
+	return exp, nil
+}
+
+func newProtocolMetricExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	switch cfg.metricProtocol() {
+	case ProtocolGRPC:
+		return grpcMetricExporter(ctx, cfg)
+	case ProtocolHTTPProtobuf:
+		return httpMetricExporter(ctx, cfg)
+	case ProtocolHTTPJSON:
+		return httpJSONMetricExporter(ctx, cfg)
+	default:
+		return nil, ErrInvalidMetricProtocol
+	}
+}
@@ -0,0 +1,175 @@
+package otelpp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	metricSamplerEffectiveRate = "otelpp.sampler.effective_rate"
+	metricSamplerLimit         = "otelpp.sampler.limit"
+
+	// samplerRateWindow is the fixed window of the effective rate.
+	samplerRateWindow = 10 * time.Second
+)
+
+// Compile-time check RateLimitingSampler implements sdktrace.Sampler.
+var _ sdktrace.Sampler = (*RateLimitingSampler)(nil)
+
+/*
+RateLimitingSampler samples at most tracesPerSecond root spans per second,
+using a token bucket that allows bursts of up to one second of traffic.
+Spans with a parent follow the decision of the parent, so whole traces are
+kept or dropped. A tracesPerSecond <= 0 samples no root span, like
+sdktrace.NeverSample.
+
+When the provider is created with a metric endpoint, the effective rate of
+sampled root spans over the last complete window of 10s is exported as the
+otelpp.sampler.effective_rate gauge.
+*/
+type RateLimitingSampler struct {
+	mu              sync.Mutex
+	tracesPerSecond float64
+	maxBalance      float64
+	balance         float64
+	lastTick        time.Time
+
+	windowStart   time.Time
+	windowSampled uint64
+	rate          float64
+
+	now func() time.Time
+}
+
+// NewRateLimitingSampler creates a RateLimitingSampler that samples at
+// most tracesPerSecond root spans per second.
+func NewRateLimitingSampler(tracesPerSecond float64) *RateLimitingSampler {
+	now := time.Now()
+	maxBalance := math.Max(tracesPerSecond, 1)
+	if tracesPerSecond <= 0 {
+		maxBalance = 0
+	}
+
+	return &RateLimitingSampler{
+		tracesPerSecond: tracesPerSecond,
+		maxBalance:      maxBalance,
+		balance:         maxBalance,
+		lastTick:        now,
+		windowStart:     now,
+		now:             time.Now,
+	}
+}
+
+// ShouldSample returns the sampling decision of the span.
+func (s *RateLimitingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+
+	if psc.IsValid() {
+		decision := sdktrace.Drop
+		if psc.IsSampled() {
+			decision = sdktrace.RecordAndSample
+		}
+		return sdktrace.SamplingResult{Decision: decision, Tracestate: psc.TraceState()}
+	}
+
+	decision := sdktrace.Drop
+	if s.allow() {
+		decision = sdktrace.RecordAndSample
+	}
+
+	return sdktrace.SamplingResult{Decision: decision, Tracestate: psc.TraceState()}
+}
+
+// Description returns the name and the limit of the sampler.
+func (s *RateLimitingSampler) Description() string {
+	return fmt.Sprintf("RateLimitingSampler{%g}", s.tracesPerSecond)
+}
+
+// RegisterMetrics exports the configured limit and the effective rate of
+// sampled root spans through m.
+func (s *RateLimitingSampler) RegisterMetrics(m Meter) error {
+	rate, err := m.Float64ObservableGauge(metricSamplerEffectiveRate,
+		instrument.WithDescription("root spans sampled per second by the rate limiting sampler"),
+		instrument.WithUnit("{trace}/s"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	limit, err := m.Float64ObservableGauge(metricSamplerLimit,
+		instrument.WithDescription("maximum root spans sampled per second by the rate limiting sampler"),
+		instrument.WithUnit("{trace}/s"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	attrs := attribute.String("sampler", "rate_limiting")
+
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveFloat64(rate, s.effectiveRate(), attrs)
+		o.ObserveFloat64(limit, s.tracesPerSecond, attrs)
+		return nil
+	}, rate, limit)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	return nil
+}
+
+func (s *RateLimitingSampler) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.roll(now)
+	s.balance = math.Min(s.maxBalance, s.balance+now.Sub(s.lastTick).Seconds()*s.tracesPerSecond)
+	s.lastTick = now
+
+	if s.balance < 1 {
+		return false
+	}
+
+	s.balance--
+	s.windowSampled++
+
+	return true
+}
+
+// effectiveRate returns the root spans sampled per second in the last
+// complete window, reading it does not reset the window so every reader
+// observes the same rate.
+func (s *RateLimitingSampler) effectiveRate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roll(s.now())
+
+	return s.rate
+}
+
+// roll closes the windows ended at now. It must be called holding mu.
+func (s *RateLimitingSampler) roll(now time.Time) {
+	elapsed := now.Sub(s.windowStart)
+	if elapsed < samplerRateWindow {
+		return
+	}
+
+	s.rate = float64(s.windowSampled) / samplerRateWindow.Seconds()
+	if elapsed >= 2*samplerRateWindow {
+		// no span was sampled in the windows after the closed one
+		s.rate = 0
+	}
+
+	s.windowStart = s.windowStart.Add(elapsed.Truncate(samplerRateWindow))
+	s.windowSampled = 0
+}
@@ -0,0 +1,296 @@
+package otelpp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"otlp-stack/pkg/opentelemetry/semconv"
+)
+
+// Environment variables of the Kubernetes downward API read by the
+// Kubernetes detector, e.g.
+//
+//	env:
+//	  - name: K8S_POD_NAME
+//	    valueFrom:
+//	      fieldRef:
+//	        fieldPath: metadata.name
+const (
+	envK8SPodName       = "K8S_POD_NAME"
+	envK8SPodUID        = "K8S_POD_UID"
+	envK8SNamespaceName = "K8S_NAMESPACE_NAME"
+	envK8SNodeName      = "K8S_NODE_NAME"
+)
+
+var (
+	cgroupFile    = "/proc/self/cgroup"
+	mountInfoFile = "/proc/self/mountinfo"
+
+	cgroupContainerIDRegexp    = regexp.MustCompile(`([0-9a-f]{64})`)
+	mountInfoContainerIDRegexp = regexp.MustCompile(`containers/([0-9a-f]{64})/`)
+
+	// defaultServiceInstanceID identifies the process, it is shared by the
+	// providers so all the signals have the same resource.
+	defaultServiceInstanceID = newServiceInstanceID()
+)
+
+// ResourceConfig - configuration for the resource describing the service
+// ServiceVersion - service.version attribute, not set by default
+// ServiceInstanceID - service.instance.id attribute, default value a random UUID generated at start
+// Detectors - attributes detected from the host, process, OS, container and Kubernetes
+// Resource - resource merged over the detected attributes and OTEL_RESOURCE_ATTRIBUTES
+// ResourceAttributes - attributes merged over Resource
+type ResourceConfig struct {
+	serviceVersion     string
+	serviceInstanceID  string
+	detectors          []resource.Option
+	resource           *resource.Resource
+	resourceAttributes map[string]string
+}
+
+/*
+createResource returns the resource of the service, merging in order, where
+the later sources override the attributes of the earlier ones:
+
+ 1. the attributes of the enabled detectors
+ 2. OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME
+ 3. the resource set with WithResource
+ 4. the attributes set with WithResourceAttributes
+ 5. the service name, environment, version and instance ID options
+
+The generated service.instance.id is used when no source sets it.
+
+The overridden attributes with a different value are reported through the
+configured Logger.
+*/
+func createResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
+	detected, err := resource.New(ctx, cfg.detectors...)
+	if errors.Is(err, resource.ErrPartialResource) {
+		cfg.Logger.Error(err, "using the partially detected resource")
+	} else if err != nil {
+		return nil, err
+	}
+
+	env, err := resource.New(ctx, resource.WithFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []struct {
+		name string
+		res  *resource.Resource
+	}{
+		{"detector", detected},
+		{"environment", env},
+		{"resource", cfg.resource},
+		{"attributes", resourceFromAttributes(cfg.resourceAttributes)},
+		{"service", serviceResource(cfg)},
+	}
+
+	res := resource.Empty()
+	for _, s := range sources {
+		res = mergeResource(cfg, res, s.res, s.name)
+	}
+
+	if _, ok := resourceValue(res, semconv.ServiceInstanceIDKey); !ok && defaultServiceInstanceID != "" {
+		id := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceInstanceID(defaultServiceInstanceID))
+		res = mergeResource(cfg, id, res, "service")
+	}
+
+	return res, nil
+}
+
+// serviceResource returns the attributes of the service set by the options.
+func serviceResource(cfg Config) *resource.Resource {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.DeploymentEnvironment(cfg.AppEnv.String()),
+	}
+
+	if cfg.serviceInstanceID != "" {
+		attrs = append(attrs, semconv.ServiceInstanceID(cfg.serviceInstanceID))
+	}
+
+	if cfg.serviceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(cfg.serviceVersion))
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
+
+// resourceFromAttributes returns a resource with the string attributes.
+func resourceFromAttributes(attrs map[string]string) *resource.Resource {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+
+	return resource.NewSchemaless(kvs...)
+}
+
+/*
+mergeResource merges override over base, logging the attributes of base
+overridden with a different value by source. When the schema URLs of the
+resources conflict, the attributes are merged without schema URL.
+*/
+func mergeResource(cfg Config, base, override *resource.Resource, source string) *resource.Resource {
+	if override == nil || override.Len() == 0 {
+		return base
+	}
+
+	for _, kv := range override.Attributes() {
+		if v, ok := resourceValue(base, kv.Key); ok && v != kv.Value {
+			cfg.Logger.Info("resource attribute overridden",
+				"key", string(kv.Key),
+				"value", v.Emit(),
+				"override", kv.Value.Emit(),
+				"source", source)
+		}
+	}
+
+	res, err := resource.Merge(base, override)
+	if err == nil {
+		return res
+	}
+
+	cfg.Logger.Error(err, "merging the resource without schema URL", "source", source)
+
+	return resource.NewSchemaless(append(base.Attributes(), override.Attributes()...)...)
+}
+
+// resourceValue returns the value of the attribute key of res, it is safe
+// to use with an empty resource, unlike the attribute.Set of the resource.
+func resourceValue(res *resource.Resource, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range res.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// hostDetector detects host.name.
+func hostDetector() []resource.Option {
+	return []resource.Option{resource.WithHost()}
+}
+
+// processDetector detects the pid, the executable and the Go runtime of the
+// process. The command line is not detected, as it may contain secrets.
+func processDetector() []resource.Option {
+	return []resource.Option{
+		resource.WithProcessPID(),
+		resource.WithProcessExecutableName(),
+		resource.WithProcessExecutablePath(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithProcessRuntimeDescription(),
+	}
+}
+
+// osDetector detects os.type.
+func osDetector() []resource.Option {
+	return []resource.Option{resource.WithOSType()}
+}
+
+// containerDetector detects container.id from the cgroup files.
+func containerDetector() []resource.Option {
+	return []resource.Option{resource.WithDetectors(detectorFunc(detectContainer))}
+}
+
+// kubernetesDetector detects the pod, namespace and node from the downward
+// API environment variables.
+func kubernetesDetector() []resource.Option {
+	return []resource.Option{resource.WithDetectors(detectorFunc(detectKubernetes))}
+}
+
+type detectorFunc func(ctx context.Context) (*resource.Resource, error)
+
+func (f detectorFunc) Detect(ctx context.Context) (*resource.Resource, error) {
+	return f(ctx)
+}
+
+// detectContainer reads the container ID from the cgroup file, used by
+// cgroup v1, or from the mount info, used by cgroup v2. Nothing is detected
+// outside a container.
+func detectContainer(_ context.Context) (*resource.Resource, error) {
+	files := []struct {
+		name string
+		re   *regexp.Regexp
+	}{
+		{cgroupFile, cgroupContainerIDRegexp},
+		{mountInfoFile, mountInfoContainerIDRegexp},
+	}
+
+	for _, file := range files {
+		id, err := containerIDFromFile(file.name, file.re)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			return resource.NewWithAttributes(semconv.SchemaURL, semconv.ContainerID(id)), nil
+		}
+	}
+
+	return resource.Empty(), nil
+}
+
+func containerIDFromFile(name string, re *regexp.Regexp) (string, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := re.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[1], nil
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+func detectKubernetes(_ context.Context) (*resource.Resource, error) {
+	keys := []struct {
+		env       string
+		attribute func(string) attribute.KeyValue
+	}{
+		{envK8SPodName, semconv.K8SPodName},
+		{envK8SPodUID, semconv.K8SPodUID},
+		{envK8SNamespaceName, semconv.K8SNamespaceName},
+		{envK8SNodeName, semconv.K8SNodeName},
+	}
+
+	var attrs []attribute.KeyValue
+	for _, k := range keys {
+		if v, ok := lookupEnv(k.env); ok {
+			attrs = append(attrs, k.attribute(v))
+		}
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+}
+
+// newServiceInstanceID returns a random version 4 UUID.
+func newServiceInstanceID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
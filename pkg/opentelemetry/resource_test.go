@@ -0,0 +1,163 @@
+package otelpp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"otlp-stack/pkg/opentelemetry/semconv"
+)
+
+func TestCreateResource_MergeOrder(t *testing.T) {
+	clearOtelEnv(t)
+	t.Setenv(envK8SPodName, "detected-pod")
+	t.Setenv(envK8SNamespaceName, "shop")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "k8s.pod.name=env-pod,team=env,tier=env,region=env")
+	t.Setenv("OTEL_SERVICE_NAME", "env-service")
+
+	var overridden []string
+	logger := funcr.New(func(_, args string) {
+		if strings.Contains(args, `"msg"="resource attribute overridden"`) {
+			overridden = append(overridden, args)
+		}
+	}, funcr.Options{})
+
+	cfg := buildConfig(
+		WithLogger(logger),
+		WithKubernetesDetector(),
+		WithResource(resource.NewSchemaless(attribute.String("tier", "resource"), attribute.String("region", "resource"))),
+		WithResourceAttributes(map[string]string{"region": "attributes"}),
+		WithServiceName("service"),
+		WithAppEnv(PROD),
+	)
+
+	res, err := createResource(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the later sources override the earlier ones
+	want := map[attribute.Key]string{
+		"k8s.namespace.name":         "shop",
+		"k8s.pod.name":               "env-pod",
+		"team":                       "env",
+		"tier":                       "resource",
+		"region":                     "attributes",
+		semconv.ServiceNameKey:       "service",
+		"deployment.environment":     "prod",
+		semconv.ServiceInstanceIDKey: defaultServiceInstanceID,
+	}
+	for k, v := range want {
+		if got, ok := resourceValue(res, k); !ok || got.Emit() != v {
+			t.Errorf("%s = %q, want %q", k, got.Emit(), v)
+		}
+	}
+	if res.Len() != len(want) {
+		t.Errorf("resource = %v, want %d attributes", res.Attributes(), len(want))
+	}
+
+	// each override with a different value is logged with its source
+	wantLogged := []string{
+		`"key"="k8s.pod.name" "value"="detected-pod" "override"="env-pod" "source"="environment"`,
+		`"key"="region" "value"="env" "override"="resource" "source"="resource"`,
+		`"key"="region" "value"="resource" "override"="attributes" "source"="attributes"`,
+		`"key"="service.name" "value"="env-service" "override"="service" "source"="service"`,
+		`"key"="tier" "value"="env" "override"="resource" "source"="resource"`,
+	}
+	if len(overridden) != len(wantLogged) {
+		t.Fatalf("logged %d overrides, want %d: %v", len(overridden), len(wantLogged), overridden)
+	}
+	sort.Strings(overridden)
+	for i, w := range wantLogged {
+		if !strings.Contains(overridden[i], w) {
+			t.Errorf("logged %s, want %s", overridden[i], w)
+		}
+	}
+}
+
+func TestCreateResource_ServiceInstanceID(t *testing.T) {
+	clearOtelEnv(t)
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.instance.id=env-instance")
+
+	res, err := createResource(context.Background(), buildConfig(WithServiceName("service")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := resourceValue(res, semconv.ServiceInstanceIDKey); got.Emit() != "env-instance" {
+		t.Errorf("service.instance.id = %q, want the one of the environment", got.Emit())
+	}
+
+	res, err = createResource(context.Background(), buildConfig(WithServiceName("service"), WithServiceInstanceID("option")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := resourceValue(res, semconv.ServiceInstanceIDKey); got.Emit() != "option" {
+		t.Errorf("service.instance.id = %q, want the one of the option", got.Emit())
+	}
+}
+
+func TestDetectContainer(t *testing.T) {
+	const id = "3f4e5d6c7b8a99887766554433221100ffeeddccbbaa00112233445566778899"
+
+	tests := []struct {
+		name      string
+		cgroup    string
+		mountInfo string
+		want      string
+	}{
+		{
+			name:   "cgroup v1",
+			cgroup: "12:pids:/docker/" + id + "\n",
+			want:   id,
+		},
+		{
+			name:      "cgroup v2",
+			cgroup:    "0::/\n",
+			mountInfo: "1 2 0:3 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw\n",
+			want:      id,
+		},
+		{
+			name:   "not in a container",
+			cgroup: "0::/user.slice\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			setResourceFile(t, &cgroupFile, filepath.Join(dir, "cgroup"), tt.cgroup)
+			setResourceFile(t, &mountInfoFile, filepath.Join(dir, "mountinfo"), tt.mountInfo)
+
+			res, err := detectContainer(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := resourceValue(res, "container.id")
+			if ok != (tt.want != "") || ok && got.AsString() != tt.want {
+				t.Errorf("container.id = %q, want %q", got.AsString(), tt.want)
+			}
+		})
+	}
+}
+
+// setResourceFile points file to name, written with content when not empty,
+// for the duration of the test.
+func setResourceFile(t *testing.T, file *string, name, content string) {
+	t.Helper()
+
+	if content != "" {
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous := *file
+	*file = name
+	t.Cleanup(func() { *file = previous })
+}
@@ -0,0 +1,230 @@
+package otelpp
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultRetryConfig is go.opentelemetry.io/otel/exporters/otlp/internal/retry.DefaultConfig,
+// used by the SDK exporters when the RetryConfig is not set.
+var defaultRetryConfig = RetryConfig{
+	Enabled:         true,
+	InitialInterval: 5 * time.Second,
+	MaxInterval:     30 * time.Second,
+	MaxElapsedTime:  time.Minute,
+}
+
+const (
+	retryMultiplier          = 1.5
+	retryRandomizationFactor = 0.5
+)
+
+// retryableError is an export error the retry loop tries again, after
+// throttle when the endpoint asked for a delay.
+type retryableError struct {
+	err      error
+	throttle time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// httpStatusError is an export answered by the HTTP endpoint with a status
+// other than 2xx.
+type httpStatusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("failed to send to %s: %s", e.url, e.status)
+}
+
+// sdkHTTPStatus matches the errors of the SDK HTTP exporters, e.g.
+// "failed to send to http://collector:4318/v1/traces: 400 Bad Request",
+// which are not typed.
+var sdkHTTPStatus = regexp.MustCompile(`^failed to send (?:metrics )?to \S+: (\d{3}) `)
+
+// retryable reports whether the export error err is transient, e.g. the
+// endpoint is unreachable or throttling, rather than a rejection of the
+// payload failing again when retried, like HTTP 400 or gRPC INVALID_ARGUMENT.
+// The gRPC errors follow grpcRetryable and the HTTP statuses 429, 502, 503
+// and 504 are transient, as specified by OTLP. The other errors are
+// transient.
+func retryable(err error) bool {
+	var r *retryableError
+	if errors.As(err, &r) {
+		return true
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		return errors.As(grpcRetryable(grpcErr.GRPCStatus().Err()), &r)
+	}
+
+	code := 0
+	var httpErr *httpStatusError
+	if errors.As(err, &httpErr) {
+		code = httpErr.code
+	} else if m := sdkHTTPStatus.FindStringSubmatch(err.Error()); m != nil {
+		code, _ = strconv.Atoi(m[1])
+	}
+
+	switch code {
+	case 0,
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// set reports whether the retry is configured, by WithRetry or by setting
+// the fields.
+func (c RetryConfig) set() bool {
+	return c.configured || c != (RetryConfig{})
+}
+
+// withDefaults returns the defaults of the SDK exporters when the retry is
+// not set, and sets the zero intervals to their default otherwise, so that
+// an unset InitialInterval does not retry in a tight loop.
+func (c RetryConfig) withDefaults() RetryConfig {
+	if !c.set() {
+		return defaultRetryConfig
+	}
+	if c.InitialInterval <= 0 {
+		c.InitialInterval = defaultRetryConfig.InitialInterval
+	}
+	if c.MaxInterval <= 0 {
+		c.MaxInterval = defaultRetryConfig.MaxInterval
+	}
+	if c.MaxElapsedTime <= 0 {
+		c.MaxElapsedTime = defaultRetryConfig.MaxElapsedTime
+	}
+	return c
+}
+
+// retry calls fn until it returns nil or an error which is not a
+// retryableError, with the exponential backoff of the SDK exporters and the
+// defaults of RetryConfig.withDefaults.
+func (c RetryConfig) retry(ctx context.Context, fn func(context.Context) error) error {
+	c = c.withDefaults()
+	if !c.Enabled {
+		return fn(ctx)
+	}
+
+	start := time.Now()
+	interval := c.InitialInterval
+	for {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var retryable *retryableError
+		if !errors.As(err, &retryable) {
+			return err
+		}
+
+		delay := jitter(interval)
+		if retryable.throttle > delay {
+			delay = retryable.throttle
+		}
+		if time.Since(start)+delay > c.MaxElapsedTime {
+			return fmt.Errorf("max retry time elapsed: %w", err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * retryMultiplier)
+		if interval > c.MaxInterval {
+			interval = c.MaxInterval
+		}
+	}
+}
+
+// jitter randomizes the interval by retryRandomizationFactor, like the
+// backoff of the SDK exporters.
+func jitter(interval time.Duration) time.Duration {
+	delta := retryRandomizationFactor * float64(interval)
+	min := float64(interval) - delta
+	return time.Duration(min + rand.Float64()*(2*delta+1))
+}
+
+// retryAfter parses the Retry-After header, in seconds or as an HTTP date.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// grpcRetryable marks the errors of the gRPC exports retried by the SDK
+// exporters as retryableError. RESOURCE_EXHAUSTED is only retried when the
+// endpoint sent a RetryInfo delay.
+func grpcRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var throttle time.Duration
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			throttle = info.GetRetryDelay().AsDuration()
+		}
+	}
+
+	switch s.Code() {
+	case codes.Canceled,
+		codes.DeadlineExceeded,
+		codes.Aborted,
+		codes.OutOfRange,
+		codes.Unavailable,
+		codes.DataLoss:
+		return &retryableError{err: err, throttle: throttle}
+	case codes.ResourceExhausted:
+		if throttle > 0 {
+			return &retryableError{err: err, throttle: throttle}
+		}
+	}
+
+	return err
+}
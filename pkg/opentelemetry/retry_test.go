@@ -0,0 +1,130 @@
+package otelpp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRetryConfig_WithDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RetryConfig
+		want RetryConfig
+	}{
+		{name: "zero", cfg: RetryConfig{}, want: defaultRetryConfig},
+		{
+			name: "enabled only",
+			cfg:  RetryConfig{Enabled: true},
+			want: defaultRetryConfig,
+		},
+		{
+			name: "max elapsed time only",
+			cfg:  RetryConfig{Enabled: true, MaxElapsedTime: time.Second},
+			want: RetryConfig{Enabled: true, InitialInterval: 5 * time.Second, MaxInterval: 30 * time.Second, MaxElapsedTime: time.Second},
+		},
+		{
+			name: "disabled by WithRetryEnable",
+			cfg:  RetryConfig{configured: true},
+			want: RetryConfig{InitialInterval: 5 * time.Second, MaxInterval: 30 * time.Second, MaxElapsedTime: time.Minute, configured: true},
+		},
+		{
+			name: "disabled",
+			cfg:  RetryConfig{InitialInterval: time.Second},
+			want: RetryConfig{InitialInterval: time.Second, MaxInterval: 30 * time.Second, MaxElapsedTime: time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cfg.withDefaults(); got != tt.want {
+			t.Errorf("%s: withDefaults() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryConfig_Retry(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+
+	// without InitialInterval the default one is used, the next attempt
+	// would be after MaxElapsedTime
+	calls := 0
+	err := RetryConfig{Enabled: true, MaxElapsedTime: time.Millisecond}.retry(context.Background(), func(context.Context) error {
+		calls++
+		return &retryableError{err: errUnavailable}
+	})
+	if !errors.Is(err, errUnavailable) || calls != 1 {
+		t.Errorf("retry = %v after %d calls, want unavailable after 1 call", err, calls)
+	}
+
+	// the throttle of the endpoint is waited for
+	calls = 0
+	err = RetryConfig{Enabled: true, InitialInterval: time.Millisecond, MaxElapsedTime: time.Second}.retry(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return &retryableError{err: errUnavailable, throttle: 10 * time.Millisecond}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("retry = %v after %d calls, want nil after 3 calls", err, calls)
+	}
+
+	// the other errors are not retried
+	calls = 0
+	err = RetryConfig{Enabled: true, InitialInterval: time.Millisecond}.retry(context.Background(), func(context.Context) error {
+		calls++
+		return errUnavailable
+	})
+	if err != errUnavailable || calls != 1 {
+		t.Errorf("retry = %v after %d calls, want unavailable after 1 call", err, calls)
+	}
+}
+
+func TestNewProvider_ShutdownOnMetricError(t *testing.T) {
+	clearOtelEnv(t)
+
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// the trace provider is created, the metric provider fails on the
+	// Prometheus address in use
+	_, _, err = NewProvider(context.Background(),
+		WithServiceName("test"),
+		WithAppEnv(DEV),
+		WithTraceEndpoint("127.0.0.1:4317"),
+		WithInsecure(true),
+		WithTrace(WithSampler(sdktrace.AlwaysSample())),
+		WithMetric(WithPrometheus(PrometheusConfig{ListenAddress: ln.Addr().String()})),
+	)
+	if err == nil {
+		t.Fatal("NewProvider: nil error, want the address in use")
+	}
+
+	// the trace provider set as global was shut down, it ignores the new
+	// span processors
+	tp, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	if !ok {
+		t.Fatalf("global trace provider %T, want the one of NewProvider", otel.GetTracerProvider())
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	tp.RegisterSpanProcessor(recorder)
+	_, span := tp.Tracer("test").Start(context.Background(), "after")
+	span.End()
+
+	if len(recorder.Ended()) != 0 {
+		t.Error("trace provider of the failed NewProvider not shut down")
+	}
+}
@@ -0,0 +1,205 @@
+package otelpp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Compile-time check RuleSampler implements sdktrace.Sampler.
+var _ sdktrace.Sampler = (*RuleSampler)(nil)
+
+/*
+SamplingRule selects the root spans matching all of its non-empty fields,
+at least one of Name, SpanKind and Attributes must be set:
+
+  - Name is a glob of the span name, where * matches any sequence of
+    characters and ? a single character, e.g. "GET /health*".
+  - SpanKind is one of internal, server, client, producer or consumer.
+  - Attributes are compared by equality with the span start attributes,
+    e.g. http.route: "/".
+
+A matching span is dropped when Drop is set, otherwise it is sampled with
+Ratio, which defaults to 1.
+*/
+type SamplingRule struct {
+	Name       string            `mapstructure:"name"`
+	SpanKind   string            `mapstructure:"span_kind"`
+	Attributes map[string]string `mapstructure:"attributes"`
+	Ratio      *float64          `mapstructure:"ratio"`
+	Drop       bool              `mapstructure:"drop"`
+}
+
+type compiledRule struct {
+	name    *regexp.Regexp
+	kind    trace.SpanKind
+	attrs   map[attribute.Key]string
+	sampler sdktrace.Sampler
+}
+
+/*
+RuleSampler evaluates an ordered list of SamplingRule for the root spans,
+the first matching rule decides and the fallback sampler is used when none
+matches. Spans with a parent follow the decision of the parent, so whole
+traces are kept or dropped.
+*/
+type RuleSampler struct {
+	rules    []compiledRule
+	fallback sdktrace.Sampler
+}
+
+// NewRuleSampler creates a RuleSampler from rules, in order, that uses
+// fallback for the root spans not matched by any rule.
+func NewRuleSampler(rules []SamplingRule, fallback sdktrace.Sampler) (*RuleSampler, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+
+	for i, r := range rules {
+		c, err := compileRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sampling rule %d: %w", i, err)
+		}
+		compiled = append(compiled, c)
+	}
+
+	if fallback == nil {
+		fallback = sdktrace.AlwaysSample()
+	}
+
+	return &RuleSampler{
+		rules:    compiled,
+		fallback: fallback,
+	}, nil
+}
+
+// ShouldSample returns the sampling decision of the span.
+func (s *RuleSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+
+	if psc.IsValid() {
+		decision := sdktrace.Drop
+		if psc.IsSampled() {
+			decision = sdktrace.RecordAndSample
+		}
+		return sdktrace.SamplingResult{Decision: decision, Tracestate: psc.TraceState()}
+	}
+
+	for _, r := range s.rules {
+		if r.matches(p) {
+			return r.sampler.ShouldSample(p)
+		}
+	}
+
+	return s.fallback.ShouldSample(p)
+}
+
+// Description returns the name of the sampler and its fallback.
+func (s *RuleSampler) Description() string {
+	return fmt.Sprintf("RuleSampler{rules:%d,fallback:%s}", len(s.rules), s.fallback.Description())
+}
+
+func compileRule(r SamplingRule) (compiledRule, error) {
+	c := compiledRule{
+		attrs: make(map[attribute.Key]string, len(r.Attributes)),
+	}
+
+	if r.Name == "" && r.SpanKind == "" && len(r.Attributes) == 0 {
+		return compiledRule{}, fmt.Errorf("missing name, span kind or attributes, the rule would match every span")
+	}
+
+	if r.Name != "" {
+		c.name = globToRegexp(r.Name)
+	}
+
+	if r.SpanKind != "" {
+		kind, err := spanKindFromString(r.SpanKind)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		c.kind = kind
+	}
+
+	for k, v := range r.Attributes {
+		c.attrs[attribute.Key(k)] = v
+	}
+
+	switch {
+	case r.Drop:
+		c.sampler = sdktrace.NeverSample()
+	case r.Ratio == nil:
+		c.sampler = sdktrace.AlwaysSample()
+	case *r.Ratio < 0 || *r.Ratio > 1:
+		return compiledRule{}, fmt.Errorf("ratio must be between 0 and 1, got %g", *r.Ratio)
+	default:
+		c.sampler = sdktrace.TraceIDRatioBased(*r.Ratio)
+	}
+
+	return c, nil
+}
+
+func (r compiledRule) matches(p sdktrace.SamplingParameters) bool {
+	if r.name != nil && !r.name.MatchString(p.Name) {
+		return false
+	}
+
+	if r.kind != trace.SpanKindUnspecified && r.kind != p.Kind {
+		return false
+	}
+
+	if len(r.attrs) == 0 {
+		return true
+	}
+
+	found := 0
+	for _, kv := range p.Attributes {
+		if want, ok := r.attrs[kv.Key]; ok {
+			if kv.Value.Emit() != want {
+				return false
+			}
+			found++
+		}
+	}
+
+	return found == len(r.attrs)
+}
+
+// globToRegexp translates a glob, where * matches any sequence of
+// characters and ? a single character, to an anchored regular expression.
+func globToRegexp(glob string) *regexp.Regexp {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.MustCompile(sb.String())
+}
+
+func spanKindFromString(kind string) (trace.SpanKind, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "internal":
+		return trace.SpanKindInternal, nil
+	case "server":
+		return trace.SpanKindServer, nil
+	case "client":
+		return trace.SpanKindClient, nil
+	case "producer":
+		return trace.SpanKindProducer, nil
+	case "consumer":
+		return trace.SpanKindConsumer, nil
+	default:
+		return trace.SpanKindUnspecified, fmt.Errorf("invalid span kind: %q", kind)
+	}
+}
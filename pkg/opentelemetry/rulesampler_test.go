@@ -0,0 +1,154 @@
+package otelpp
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func ratio(r float64) *float64 {
+	return &r
+}
+
+func TestRuleSampler(t *testing.T) {
+	sampler, err := NewRuleSampler([]SamplingRule{
+		{Name: "GET /health*", Drop: true},
+		{SpanKind: "consumer", Ratio: ratio(0)},
+		{Attributes: map[string]string{"http.route": "/private", "tenant": "internal"}},
+		{Name: "job-?", SpanKind: "internal"},
+	}, sdktrace.NeverSample())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		params sdktrace.SamplingParameters
+		want   sdktrace.SamplingDecision
+	}{
+		{
+			name:   "name glob drops",
+			params: sdktrace.SamplingParameters{Name: "GET /healthz", Kind: trace.SpanKindServer},
+			want:   sdktrace.Drop,
+		},
+		{
+			name:   "span kind with ratio 0 drops",
+			params: sdktrace.SamplingParameters{Name: "orders process", Kind: trace.SpanKindConsumer},
+			want:   sdktrace.Drop,
+		},
+		{
+			name: "all attributes match",
+			params: sdktrace.SamplingParameters{Name: "GET /private", Attributes: []attribute.KeyValue{
+				attribute.String("http.route", "/private"), attribute.String("tenant", "internal"),
+			}},
+			want: sdktrace.RecordAndSample,
+		},
+		{
+			name: "missing attribute falls back",
+			params: sdktrace.SamplingParameters{Name: "GET /private", Attributes: []attribute.KeyValue{
+				attribute.String("http.route", "/private"),
+			}},
+			want: sdktrace.Drop,
+		},
+		{
+			name:   "name and kind sample",
+			params: sdktrace.SamplingParameters{Name: "job-1", Kind: trace.SpanKindInternal},
+			want:   sdktrace.RecordAndSample,
+		},
+		{
+			name:   "name without kind falls back",
+			params: sdktrace.SamplingParameters{Name: "job-1", Kind: trace.SpanKindClient},
+			want:   sdktrace.Drop,
+		},
+		{
+			name:   "glob ? matches a single character",
+			params: sdktrace.SamplingParameters{Name: "job-12", Kind: trace.SpanKindInternal},
+			want:   sdktrace.Drop,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sampler.ShouldSample(tt.params).Decision; got != tt.want {
+				t.Errorf("decision = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleSampler_AttributeValueMismatch(t *testing.T) {
+	sampler, err := NewRuleSampler([]SamplingRule{
+		{Attributes: map[string]string{"http.status_code": "500"}},
+	}, sdktrace.NeverSample())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := func(code int) sdktrace.SamplingParameters {
+		return sdktrace.SamplingParameters{Attributes: []attribute.KeyValue{attribute.Int("http.status_code", code)}}
+	}
+
+	if got := sampler.ShouldSample(params(500)).Decision; got != sdktrace.RecordAndSample {
+		t.Errorf("decision of 500 = %v, want RecordAndSample", got)
+	}
+	if got := sampler.ShouldSample(params(200)).Decision; got != sdktrace.Drop {
+		t.Errorf("decision of 200 = %v, want Drop", got)
+	}
+}
+
+func TestRuleSampler_FollowsParent(t *testing.T) {
+	sampler, err := NewRuleSampler([]SamplingRule{{Name: "*", Drop: true}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, flags := range []trace.TraceFlags{0, trace.FlagsSampled} {
+		parent := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     trace.SpanID{1},
+			TraceFlags: flags,
+		})
+		ctx := trace.ContextWithSpanContext(context.Background(), parent)
+
+		want := sdktrace.Drop
+		if flags.IsSampled() {
+			want = sdktrace.RecordAndSample
+		}
+
+		got := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: ctx, Name: "child"}).Decision
+		if got != want {
+			t.Errorf("decision with parent flags %v = %v, want %v", flags, got, want)
+		}
+	}
+}
+
+func TestRuleSampler_DefaultFallbackSamples(t *testing.T) {
+	sampler, err := NewRuleSampler(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sampler.ShouldSample(sdktrace.SamplingParameters{Name: "any"}).Decision; got != sdktrace.RecordAndSample {
+		t.Errorf("decision = %v, want RecordAndSample", got)
+	}
+}
+
+func TestNewRuleSampler_InvalidRules(t *testing.T) {
+	tests := map[string]SamplingRule{
+		"ratio above 1":  {Name: "*", Ratio: ratio(1.5)},
+		"negative ratio": {Name: "*", Ratio: ratio(-0.1)},
+		"unknown kind":   {SpanKind: "gateway"},
+		"empty":          {Drop: true},
+	}
+
+	for name, rule := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewRuleSampler([]SamplingRule{rule}, nil); err == nil {
+				t.Error("NewRuleSampler succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package otelpp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	envTracesSampler    = "OTEL_TRACES_SAMPLER"
+	envTracesSamplerArg = "OTEL_TRACES_SAMPLER_ARG"
+)
+
+// defaultSamplingRatio is the parent-based ratio used when neither
+// WithSampler nor OTEL_TRACES_SAMPLER are set.
+var defaultSamplingRatio = map[EnvLevel]float64{
+	DEV:  1,
+	QA:   0.5,
+	PROD: 0.1,
+}
+
+// NewRatioSampler samples the given ratio of the traces, ignoring the
+// decision of the parent span. ratio >= 1 samples all, ratio <= 0 none.
+func NewRatioSampler(ratio float64) sdktrace.Sampler {
+	return sdktrace.TraceIDRatioBased(ratio)
+}
+
+// NewParentBasedRatioSampler follows the decision of the parent span and
+// samples the given ratio of the root spans.
+func NewParentBasedRatioSampler(ratio float64) sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// NewAlwaysOffSampler drops every span.
+func NewAlwaysOffSampler() sdktrace.Sampler {
+	return sdktrace.NeverSample()
+}
+
+// NewAlwaysOnSampler records and samples every span.
+func NewAlwaysOnSampler() sdktrace.Sampler {
+	return sdktrace.AlwaysSample()
+}
+
+// DefaultSampler returns the sampler used for envLevel when no sampler is
+// configured: parent-based with 100% of the root spans in DEV, 50% in QA
+// and 10% in PROD.
+func DefaultSampler(envLevel EnvLevel) sdktrace.Sampler {
+	ratio, ok := defaultSamplingRatio[envLevel]
+	if !ok {
+		ratio = 1
+	}
+	return NewParentBasedRatioSampler(ratio)
+}
+
+// createSampler returns the sampler of the tracer provider: the sampling
+// rules, when set, in front of the base sampler.
+func createSampler(cfg Config) (sdktrace.Sampler, error) {
+	base := createBaseSampler(cfg)
+	if len(cfg.samplingRules) == 0 {
+		return base, nil
+	}
+
+	return NewRuleSampler(cfg.samplingRules, base)
+}
+
+// createBaseSampler returns the sampler set with WithSampler, then the one
+// from OTEL_TRACES_SAMPLER and finally the default of the AppEnv, or always
+// on when tail sampling decides which traces are kept.
+func createBaseSampler(cfg Config) sdktrace.Sampler {
+	if cfg.sampler != nil {
+		return cfg.sampler
+	}
+
+	if name, ok := os.LookupEnv(envTracesSampler); ok {
+		s, err := samplerFromEnv(name, os.Getenv(envTracesSamplerArg))
+		if err == nil {
+			return s
+		}
+		cfg.Logger.Error(err, "ignoring sampler from environment", "env", envTracesSampler)
+	}
+
+	if cfg.tailSampling != nil {
+		return NewAlwaysOnSampler()
+	}
+
+	return DefaultSampler(cfg.AppEnv)
+}
+
+// samplerFromEnv translates the OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG
+// values to a sampler, as defined by the OpenTelemetry specification.
+func samplerFromEnv(name, arg string) (sdktrace.Sampler, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	switch name {
+	case "always_on":
+		return NewAlwaysOnSampler(), nil
+	case "always_off":
+		return NewAlwaysOffSampler(), nil
+	case "parentbased_always_on":
+		return sdktrace.ParentBased(NewAlwaysOnSampler()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(NewAlwaysOffSampler()), nil
+	case "traceidratio":
+		ratio, err := samplerRatioFromEnv(arg)
+		if err != nil {
+			return nil, err
+		}
+		return NewRatioSampler(ratio), nil
+	case "parentbased_traceidratio":
+		ratio, err := samplerRatioFromEnv(arg)
+		if err != nil {
+			return nil, err
+		}
+		return NewParentBasedRatioSampler(ratio), nil
+	default:
+		return nil, fmt.Errorf("unsupported sampler: %q", name)
+	}
+}
+
+// samplerRatioFromEnv parses the OTEL_TRACES_SAMPLER_ARG, which defaults to 1.
+func samplerRatioFromEnv(arg string) (float64, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return 1, nil
+	}
+
+	ratio, err := strconv.ParseFloat(arg, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return 0, fmt.Errorf("invalid sampler ratio: %q, must be between 0 and 1", arg)
+	}
+
+	return ratio, nil
+}
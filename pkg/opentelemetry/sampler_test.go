@@ -0,0 +1,89 @@
+package otelpp
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestCreateBaseSampler_Env(t *testing.T) {
+	tests := []struct {
+		name    string
+		sampler string
+		arg     string
+		appEnv  EnvLevel
+		want    sdktrace.Sampler
+		invalid bool
+	}{
+		{name: "always on", sampler: "always_on", want: sdktrace.AlwaysSample()},
+		{name: "always off", sampler: " ALWAYS_OFF ", want: sdktrace.NeverSample()},
+		{name: "ratio", sampler: "traceidratio", arg: "0.25", want: sdktrace.TraceIDRatioBased(0.25)},
+		{name: "ratio default arg", sampler: "traceidratio", want: sdktrace.TraceIDRatioBased(1)},
+		{name: "parent based always on", sampler: "parentbased_always_on", want: sdktrace.ParentBased(sdktrace.AlwaysSample())},
+		{name: "parent based always off", sampler: "parentbased_always_off", want: sdktrace.ParentBased(sdktrace.NeverSample())},
+		{name: "parent based ratio", sampler: "parentbased_traceidratio", arg: "0.3", want: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.3))},
+		{name: "ratio above 1", sampler: "traceidratio", arg: "1.5", appEnv: PROD, want: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.1)), invalid: true},
+		{name: "negative ratio", sampler: "parentbased_traceidratio", arg: "-0.1", appEnv: QA, want: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.5)), invalid: true},
+		{name: "ratio not a number", sampler: "traceidratio", arg: "half", appEnv: DEV, want: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(1)), invalid: true},
+		{name: "unsupported sampler", sampler: "jaeger_remote", appEnv: PROD, want: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.1)), invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOtelEnv(t)
+			t.Setenv(envTracesSampler, tt.sampler)
+			t.Setenv(envTracesSamplerArg, tt.arg)
+
+			var logged int
+			cfg := Config{Logger: funcr.New(func(_, _ string) { logged++ }, funcr.Options{})}
+			cfg.AppEnv = tt.appEnv
+
+			got := createBaseSampler(cfg)
+			if got.Description() != tt.want.Description() {
+				t.Errorf("sampler = %s, want %s", got.Description(), tt.want.Description())
+			}
+			if invalid := logged > 0; invalid != tt.invalid {
+				t.Errorf("logged %d errors, want invalid %v", logged, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestCreateBaseSampler_Defaults(t *testing.T) {
+	tests := []struct {
+		appEnv EnvLevel
+		ratio  float64
+	}{
+		{appEnv: DEV, ratio: 1},
+		{appEnv: QA, ratio: 0.5},
+		{appEnv: PROD, ratio: 0.1},
+		{appEnv: INVALID, ratio: 1},
+	}
+
+	clearOtelEnv(t)
+
+	for _, tt := range tests {
+		cfg := Config{}
+		cfg.AppEnv = tt.appEnv
+
+		want := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(tt.ratio)).Description()
+		if got := createBaseSampler(cfg).Description(); got != want {
+			t.Errorf("%s: sampler = %s, want %s", tt.appEnv, got, want)
+		}
+	}
+
+	// the sampler option and the tail sampling take precedence over the
+	// default of the AppEnv
+	cfg := Config{}
+	cfg.AppEnv = PROD
+	cfg.tailSampling = &TailSamplingConfig{}
+	if got := createBaseSampler(cfg).Description(); got != sdktrace.AlwaysSample().Description() {
+		t.Errorf("tail sampling: sampler = %s, want AlwaysOnSampler", got)
+	}
+
+	cfg.sampler = sdktrace.NeverSample()
+	if got := createBaseSampler(cfg).Description(); got != sdktrace.NeverSample().Description() {
+		t.Errorf("WithSampler: sampler = %s, want AlwaysOffSampler", got)
+	}
+}
@@ -0,0 +1,37 @@
+package semconv
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// DBSystem returns the db.system attribute, like postgresql, mysql or redis.
+func DBSystem(system string) attribute.KeyValue {
+	return semconv.DBSystemKey.String(system)
+}
+
+// DBName returns the db.name attribute.
+func DBName(name string) attribute.KeyValue {
+	return semconv.DBName(name)
+}
+
+// DBUser returns the db.user attribute.
+func DBUser(user string) attribute.KeyValue {
+	return semconv.DBUser(user)
+}
+
+// DBStatement returns the db.statement attribute, which must not contain
+// sensitive values.
+func DBStatement(statement string) attribute.KeyValue {
+	return semconv.DBStatement(statement)
+}
+
+// DBOperation returns the db.operation attribute, like SELECT or findAndModify.
+func DBOperation(operation string) attribute.KeyValue {
+	return semconv.DBOperation(operation)
+}
+
+// DBSQLTable returns the db.sql.table attribute.
+func DBSQLTable(table string) attribute.KeyValue {
+	return semconv.DBSQLTable(table)
+}
@@ -0,0 +1,70 @@
+package semconv
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+)
+
+// HTTPMethod returns the http.method attribute.
+func HTTPMethod(method string) attribute.KeyValue {
+	return semconv.HTTPMethod(method)
+}
+
+// HTTPRoute returns the http.route attribute, the matched route template
+// like /users/:id, not the path of the request.
+func HTTPRoute(route string) attribute.KeyValue {
+	return semconv.HTTPRoute(route)
+}
+
+// HTTPTarget returns the http.target attribute, the path and query of the
+// request.
+func HTTPTarget(target string) attribute.KeyValue {
+	return semconv.HTTPTarget(target)
+}
+
+// HTTPScheme returns the http.scheme attribute.
+func HTTPScheme(scheme string) attribute.KeyValue {
+	return semconv.HTTPScheme(scheme)
+}
+
+// HTTPURL returns the http.url attribute of a client request.
+func HTTPURL(url string) attribute.KeyValue {
+	return semconv.HTTPURL(url)
+}
+
+// HTTPStatusCode returns the http.status_code attribute.
+func HTTPStatusCode(code int) attribute.KeyValue {
+	return semconv.HTTPStatusCode(code)
+}
+
+// HTTPServerRequest returns the attributes of a request received by server,
+// the name of the server or "" to use the Host of the request.
+func HTTPServerRequest(server string, req *http.Request) []attribute.KeyValue {
+	return httpconv.ServerRequest(server, req)
+}
+
+// HTTPClientRequest returns the attributes of a request sent by a client.
+func HTTPClientRequest(req *http.Request) []attribute.KeyValue {
+	return httpconv.ClientRequest(req)
+}
+
+// HTTPClientResponse returns the attributes of a response received by a client.
+func HTTPClientResponse(resp *http.Response) []attribute.KeyValue {
+	return httpconv.ClientResponse(resp)
+}
+
+// HTTPServerStatus returns the span status of a server span for the HTTP
+// status code, only 5xx codes are errors.
+func HTTPServerStatus(code int) (codes.Code, string) {
+	return httpconv.ServerStatus(code)
+}
+
+// HTTPClientStatus returns the span status of a client span for the HTTP
+// status code, 4xx and 5xx codes are errors.
+func HTTPClientStatus(code int) (codes.Code, string) {
+	return httpconv.ClientStatus(code)
+}
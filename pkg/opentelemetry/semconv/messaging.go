@@ -0,0 +1,40 @@
+package semconv
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Values of the messaging.operation attribute.
+var (
+	MessagingOperationPublish = semconv.MessagingOperationPublish
+	MessagingOperationReceive = semconv.MessagingOperationReceive
+	MessagingOperationProcess = semconv.MessagingOperationProcess
+)
+
+// MessagingSystem returns the messaging.system attribute, like kafka or rabbitmq.
+func MessagingSystem(system string) attribute.KeyValue {
+	return semconv.MessagingSystem(system)
+}
+
+// MessagingDestinationName returns the messaging.destination.name attribute
+// of the topic or queue the message is sent to.
+func MessagingDestinationName(name string) attribute.KeyValue {
+	return semconv.MessagingDestinationName(name)
+}
+
+// MessagingSourceName returns the messaging.source.name attribute of the
+// topic or queue the message is received from.
+func MessagingSourceName(name string) attribute.KeyValue {
+	return semconv.MessagingSourceName(name)
+}
+
+// MessagingMessageID returns the messaging.message.id attribute.
+func MessagingMessageID(id string) attribute.KeyValue {
+	return semconv.MessagingMessageID(id)
+}
+
+// MessagingKafkaConsumerGroup returns the messaging.kafka.consumer.group attribute.
+func MessagingKafkaConsumerGroup(group string) attribute.KeyValue {
+	return semconv.MessagingKafkaConsumerGroup(group)
+}
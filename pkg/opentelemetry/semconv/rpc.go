@@ -0,0 +1,39 @@
+package semconv
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// RPCSystem returns the rpc.system attribute, like grpc or jsonrpc.
+func RPCSystem(system string) attribute.KeyValue {
+	return semconv.RPCSystemKey.String(system)
+}
+
+// RPCSystemGRPC is the rpc.system attribute of gRPC.
+var RPCSystemGRPC = semconv.RPCSystemGRPC
+
+// RPCService returns the rpc.service attribute, the full name of the service.
+func RPCService(service string) attribute.KeyValue {
+	return semconv.RPCService(service)
+}
+
+// RPCMethod returns the rpc.method attribute.
+func RPCMethod(method string) attribute.KeyValue {
+	return semconv.RPCMethod(method)
+}
+
+// RPCGRPCStatusCode returns the rpc.grpc.status_code attribute.
+func RPCGRPCStatusCode(code int) attribute.KeyValue {
+	return semconv.RPCGRPCStatusCodeKey.Int(code)
+}
+
+// NetPeerName returns the net.peer.name attribute of the remote host.
+func NetPeerName(name string) attribute.KeyValue {
+	return semconv.NetPeerName(name)
+}
+
+// NetPeerPort returns the net.peer.port attribute of the remote host.
+func NetPeerPort(port int) attribute.KeyValue {
+	return semconv.NetPeerPort(port)
+}
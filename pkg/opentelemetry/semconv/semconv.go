@@ -0,0 +1,116 @@
+/*
+Package semconv provides typed helpers for the OpenTelemetry semantic
+conventions used by otelpp and the instrumented services, so the attribute
+names are never written by hand.
+
+The helpers are pinned to the semantic conventions version of SchemaURL,
+the one used by the resource detectors of the SDK. Upgrading the version is
+done here, without changing the code using the helpers.
+*/
+package semconv
+
+import (
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// SchemaURL is the schema URL of the semantic conventions of the helpers.
+const SchemaURL = semconv.SchemaURL
+
+// ServiceNameKey is the key of the service.name resource attribute.
+const ServiceNameKey = semconv.ServiceNameKey
+
+// ServiceName returns the service.name resource attribute.
+func ServiceName(name string) attribute.KeyValue {
+	return semconv.ServiceName(name)
+}
+
+// ServiceVersion returns the service.version resource attribute.
+func ServiceVersion(version string) attribute.KeyValue {
+	return semconv.ServiceVersion(version)
+}
+
+// ServiceInstanceIDKey is the key of the service.instance.id resource attribute.
+const ServiceInstanceIDKey = semconv.ServiceInstanceIDKey
+
+// ServiceInstanceID returns the service.instance.id resource attribute.
+func ServiceInstanceID(id string) attribute.KeyValue {
+	return semconv.ServiceInstanceID(id)
+}
+
+// DeploymentEnvironment returns the deployment.environment resource attribute.
+func DeploymentEnvironment(env string) attribute.KeyValue {
+	return semconv.DeploymentEnvironment(env)
+}
+
+// ContainerID returns the container.id resource attribute.
+func ContainerID(id string) attribute.KeyValue {
+	return semconv.ContainerID(id)
+}
+
+// K8SPodName returns the k8s.pod.name resource attribute.
+func K8SPodName(name string) attribute.KeyValue {
+	return semconv.K8SPodName(name)
+}
+
+// K8SPodUID returns the k8s.pod.uid resource attribute.
+func K8SPodUID(uid string) attribute.KeyValue {
+	return semconv.K8SPodUID(uid)
+}
+
+// K8SNamespaceName returns the k8s.namespace.name resource attribute.
+func K8SNamespaceName(name string) attribute.KeyValue {
+	return semconv.K8SNamespaceName(name)
+}
+
+// K8SNodeName returns the k8s.node.name resource attribute.
+func K8SNodeName(name string) attribute.KeyValue {
+	return semconv.K8SNodeName(name)
+}
+
+// CodeFilepath returns the code.filepath attribute of the source file.
+func CodeFilepath(path string) attribute.KeyValue {
+	return semconv.CodeFilepath(path)
+}
+
+// CodeLineNumber returns the code.lineno attribute of the source line.
+func CodeLineNumber(line int) attribute.KeyValue {
+	return semconv.CodeLineNumber(line)
+}
+
+// CodeFunction returns the code.function attribute of the source function.
+func CodeFunction(function string) attribute.KeyValue {
+	return semconv.CodeFunction(function)
+}
+
+// ExceptionEventName is the name of the span event recording an exception.
+const ExceptionEventName = semconv.ExceptionEventName
+
+// Exception returns the exception.type and exception.message attributes of err.
+func Exception(err error) []attribute.KeyValue {
+	if err == nil {
+		return nil
+	}
+
+	return []attribute.KeyValue{
+		semconv.ExceptionType(errorType(err)),
+		semconv.ExceptionMessage(err.Error()),
+	}
+}
+
+// ExceptionStacktrace returns the exception.stacktrace attribute.
+func ExceptionStacktrace(stacktrace string) attribute.KeyValue {
+	return semconv.ExceptionStacktrace(stacktrace)
+}
+
+// errorType returns the package qualified type of err, like the SDK does
+// for span.RecordError.
+func errorType(err error) string {
+	t := reflect.TypeOf(err)
+	if t.PkgPath() == "" && t.Name() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
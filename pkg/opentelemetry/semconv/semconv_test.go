@@ -0,0 +1,112 @@
+package semconv_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"otlp-stack/pkg/opentelemetry/semconv"
+)
+
+// TestAttributeNames pins the names of the attributes, a version of the
+// semantic conventions renaming one must be reviewed with its users.
+func TestAttributeNames(t *testing.T) {
+	tests := []struct {
+		got  attribute.KeyValue
+		want string
+	}{
+		{semconv.ServiceName("api"), "service.name=api"},
+		{semconv.ServiceVersion("1.2.0"), "service.version=1.2.0"},
+		{semconv.ServiceInstanceID("i-1"), "service.instance.id=i-1"},
+		{semconv.DeploymentEnvironment("prod"), "deployment.environment=prod"},
+		{semconv.ContainerID("c"), "container.id=c"},
+		{semconv.K8SPodName("p"), "k8s.pod.name=p"},
+		{semconv.K8SPodUID("u"), "k8s.pod.uid=u"},
+		{semconv.K8SNamespaceName("n"), "k8s.namespace.name=n"},
+		{semconv.K8SNodeName("n"), "k8s.node.name=n"},
+		{semconv.CodeFilepath("main.go"), "code.filepath=main.go"},
+		{semconv.CodeLineNumber(12), "code.lineno=12"},
+		{semconv.CodeFunction("main"), "code.function=main"},
+		{semconv.HTTPMethod("GET"), "http.method=GET"},
+		{semconv.HTTPRoute("/users/:id"), "http.route=/users/:id"},
+		{semconv.HTTPTarget("/users/1"), "http.target=/users/1"},
+		{semconv.HTTPStatusCode(200), "http.status_code=200"},
+		{semconv.DBSystem("postgresql"), "db.system=postgresql"},
+		{semconv.DBStatement("SELECT 1"), "db.statement=SELECT 1"},
+		{semconv.DBSQLTable("users"), "db.sql.table=users"},
+		{semconv.RPCSystemGRPC, "rpc.system=grpc"},
+		{semconv.RPCGRPCStatusCode(14), "rpc.grpc.status_code=14"},
+		{semconv.NetPeerPort(4317), "net.peer.port=4317"},
+		{semconv.MessagingSystem("kafka"), "messaging.system=kafka"},
+		{semconv.MessagingDestinationName("orders"), "messaging.destination.name=orders"},
+		{semconv.MessagingSourceName("orders"), "messaging.source.name=orders"},
+		{semconv.MessagingOperationProcess, "messaging.operation=process"},
+		{semconv.MessagingKafkaConsumerGroup("billing"), "messaging.kafka.consumer.group=billing"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprintf("%s=%s", tt.got.Key, tt.got.Value.Emit()); got != tt.want {
+			t.Errorf("attribute = %s, want %s", got, tt.want)
+		}
+	}
+
+	if semconv.SchemaURL != "https://opentelemetry.io/schemas/1.17.0" {
+		t.Errorf("SchemaURL = %s, want the one of v1.17.0", semconv.SchemaURL)
+	}
+}
+
+func TestException(t *testing.T) {
+	if attrs := semconv.Exception(nil); attrs != nil {
+		t.Errorf("Exception(nil) = %v, want nil", attrs)
+	}
+
+	tests := []struct {
+		err      error
+		wantType string
+	}{
+		{errors.New("boom"), "*errors.errorString"},
+		{&fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist}, "*fs.PathError"},
+		{syscall.ENOENT, "syscall.Errno"},
+	}
+
+	for _, tt := range tests {
+		attrs := semconv.Exception(tt.err)
+		if len(attrs) != 2 {
+			t.Fatalf("Exception(%v) = %v, want type and message", tt.err, attrs)
+		}
+		if attrs[0].Key != "exception.type" || attrs[0].Value.AsString() != tt.wantType {
+			t.Errorf("exception type = %s=%s, want %s", attrs[0].Key, attrs[0].Value.AsString(), tt.wantType)
+		}
+		if attrs[1].Key != "exception.message" || attrs[1].Value.AsString() != tt.err.Error() {
+			t.Errorf("exception message = %s=%s, want %s", attrs[1].Key, attrs[1].Value.AsString(), tt.err)
+		}
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/users?id=1", nil)
+
+	attrs := attribute.NewSet(semconv.HTTPServerRequest("", req)...)
+	if v, ok := attrs.Value("http.method"); !ok || v.AsString() != "GET" {
+		t.Errorf("http.method = %v, want GET", v.Emit())
+	}
+	if v, ok := attrs.Value("net.host.name"); !ok || v.AsString() != "example.com" {
+		t.Errorf("net.host.name = %v, want example.com", v.Emit())
+	}
+
+	// only the 5xx codes are errors of a server span, 4xx too of a client span
+	if code, _ := semconv.HTTPServerStatus(404); code != codes.Unset {
+		t.Errorf("server status of 404 = %v, want Unset", code)
+	}
+	if code, _ := semconv.HTTPServerStatus(503); code != codes.Error {
+		t.Errorf("server status of 503 = %v, want Error", code)
+	}
+	if code, _ := semconv.HTTPClientStatus(404); code != codes.Error {
+		t.Errorf("client status of 404 = %v, want Error", code)
+	}
+}
@@ -0,0 +1,446 @@
+package otelpp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultSpoolMaxSize       = 100 << 20
+	defaultSpoolMaxAge        = 24 * time.Hour
+	defaultSpoolRetryInterval = 5 * time.Second
+	defaultSpoolReplayTimeout = 30 * time.Second
+
+	spoolFileExt = ".otlp"
+
+	metricSpoolItems  = "otelpp.spool.items"
+	metricSpoolQueued = "otelpp.spool.queued"
+	metricSpoolSize   = "otelpp.spool.size"
+)
+
+var ErrSpoolItemTooLarge = errors.New("payload larger than the spool max size")
+
+// errInvalidSpoolPayload is returned by the replays of the payloads which
+// cannot be decoded.
+var errInvalidSpoolPayload = errors.New("invalid spooled payload")
+
+/*
+SpoolConfig - configuration of the disk-backed export queue
+Directory - directory of the queue, one sub directory per signal, required
+MaxSize - maximum bytes of the queue of each signal, the oldest payloads are dropped first, default value 100MiB
+MaxAge - payloads older than MaxAge are dropped instead of replayed, default value 24h
+RetryInterval - interval between the replays of the queued payloads, default value 5s
+*/
+type SpoolConfig struct {
+	Directory     string
+	MaxSize       int64
+	MaxAge        time.Duration
+	RetryInterval time.Duration
+}
+
+func (c SpoolConfig) withDefaults() SpoolConfig {
+	if c.MaxSize <= 0 {
+		c.MaxSize = defaultSpoolMaxSize
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = defaultSpoolMaxAge
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = defaultSpoolRetryInterval
+	}
+	return c
+}
+
+type spoolItem struct {
+	name    string
+	size    int64
+	created time.Time
+}
+
+/*
+diskQueue is a FIFO of payloads stored as one file per payload. A payload is
+written to a temporary file and renamed, so a crash never leaves a partial
+payload in the queue, and the files left by a previous process are queued
+again when the queue is opened.
+*/
+type diskQueue struct {
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+
+	mu      sync.Mutex
+	items   []spoolItem
+	size    int64
+	seq     uint64
+	dropped uint64
+
+	now func() time.Time
+}
+
+func openDiskQueue(dir string, maxSize int64, maxAge time.Duration) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	q := &diskQueue{
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		now:     time.Now,
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), spoolFileExt) {
+			continue
+		}
+
+		created, ok := spoolItemTime(e.Name())
+		info, err := e.Info()
+		if !ok || err != nil {
+			continue
+		}
+
+		q.items = append(q.items, spoolItem{name: e.Name(), size: info.Size(), created: created})
+		q.size += info.Size()
+	}
+
+	sort.Slice(q.items, func(i, j int) bool {
+		return q.items[i].name < q.items[j].name
+	})
+
+	return q, nil
+}
+
+// spoolItemTime returns the creation time encoded in the file name.
+func spoolItemTime(name string) (time.Time, bool) {
+	nanos, _, ok := strings.Cut(strings.TrimSuffix(name, spoolFileExt), "-")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, n), true
+}
+
+// push appends data to the queue, dropping the oldest payloads above maxSize.
+func (q *diskQueue) push(data []byte) error {
+	size := int64(len(data))
+	if size > q.maxSize {
+		q.mu.Lock()
+		q.dropped++
+		q.mu.Unlock()
+		return ErrSpoolItemTooLarge
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	created := q.now()
+	q.seq++
+	name := fmt.Sprintf("%020d-%06d%s", created.UnixNano(), q.seq%1000000, spoolFileExt)
+
+	tmp := filepath.Join(q.dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+	if err := os.Rename(tmp, filepath.Join(q.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return errors.Wrap(err, err.Error())
+	}
+
+	q.items = append(q.items, spoolItem{name: name, size: size, created: created})
+	q.size += size
+
+	for q.size > q.maxSize && len(q.items) > 1 {
+		q.dropLocked(q.items[0])
+	}
+
+	return nil
+}
+
+// peek returns the oldest payload of the queue, dropping the expired ones.
+func (q *diskQueue) peek() (spoolItem, []byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) > 0 {
+		item := q.items[0]
+
+		if q.now().Sub(item.created) > q.maxAge {
+			q.dropLocked(item)
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(q.dir, item.name))
+		if err != nil {
+			q.dropLocked(item)
+			continue
+		}
+
+		return item, data, true
+	}
+
+	return spoolItem{}, nil, false
+}
+
+// drop deletes item from the queue without replaying it.
+func (q *diskQueue) drop(item spoolItem) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.dropLocked(item)
+}
+
+// remove deletes item from the queue once it has been replayed.
+func (q *diskQueue) remove(item spoolItem) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.removeLocked(item) {
+		_ = os.Remove(filepath.Join(q.dir, item.name))
+	}
+}
+
+func (q *diskQueue) dropLocked(item spoolItem) {
+	if q.removeLocked(item) {
+		_ = os.Remove(filepath.Join(q.dir, item.name))
+		q.dropped++
+	}
+}
+
+func (q *diskQueue) removeLocked(item spoolItem) bool {
+	for i, it := range q.items {
+		if it.name == item.name {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			q.size -= it.size
+			return true
+		}
+	}
+	return false
+}
+
+func (q *diskQueue) stats() (queued int, size int64, dropped uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.items), q.size, q.dropped
+}
+
+/*
+spooler stores the payloads that failed to be exported with a transient
+error in a diskQueue and replays them, oldest first, every RetryInterval
+until the endpoint accepts them again. The payloads which cannot be decoded
+or are rejected by the endpoint are dropped, so they do not block the
+following ones.
+*/
+type spooler struct {
+	signal      string
+	destination string
+	queue       *diskQueue
+	upload      func(ctx context.Context, data []byte) error
+	interval    time.Duration
+	logger      logr.Logger
+
+	mu       sync.Mutex
+	spooled  uint64
+	replayed uint64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func newSpooler(cfg Config, signal string, upload func(ctx context.Context, data []byte) error) (*spooler, error) {
+	spoolCfg := cfg.spool.withDefaults()
+	if spoolCfg.Directory == "" {
+		return nil, errors.New("missing spool directory")
+	}
+
+	queue, err := openDiskQueue(filepath.Join(spoolCfg.Directory, signal), spoolCfg.MaxSize, spoolCfg.MaxAge)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	return &spooler{
+		signal:      signal,
+		destination: cfg.destinationName,
+		queue:       queue,
+		upload:      upload,
+		interval:    spoolCfg.RetryInterval,
+		logger:      cfg.Logger,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// start replays the queued payloads in background until close, it is called
+// when the spooling client or exporter is created.
+func (s *spooler) start() {
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+}
+
+func (s *spooler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), defaultSpoolReplayTimeout)
+			s.replay(ctx)
+			cancel()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// replay uploads the queued payloads until the queue is empty or an upload
+// fails with a transient error. The payloads failing with another error are
+// dropped.
+func (s *spooler) replay(ctx context.Context) {
+	for ctx.Err() == nil {
+		item, data, ok := s.queue.peek()
+		if !ok {
+			return
+		}
+
+		if err := s.upload(ctx, data); err != nil {
+			if !errors.Is(err, errInvalidSpoolPayload) && retryable(err) {
+				return
+			}
+
+			s.queue.drop(item)
+			s.logger.Error(err, "spooled payload dropped", "signal", s.signal)
+			continue
+		}
+
+		s.queue.remove(item)
+
+		s.mu.Lock()
+		s.replayed++
+		s.mu.Unlock()
+	}
+}
+
+// spool stores msg, which failed to be exported with cause. cause is
+// returned when it is not transient, msg is then not stored.
+func (s *spooler) spool(msg proto.Message, cause error) error {
+	if !retryable(cause) {
+		return cause
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	if err = s.queue.push(data); err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	s.mu.Lock()
+	s.spooled++
+	s.mu.Unlock()
+
+	s.logger.Info("export failed, payload spooled to disk", "signal", s.signal, "error", cause.Error())
+
+	return nil
+}
+
+// close stops the replays, the queued payloads stay on disk for the next
+// process.
+func (s *spooler) close(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	s.startOnce.Do(func() {
+		close(s.done)
+	})
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RegisterMetrics exports the spooled, replayed and dropped payloads and
+// the size of the queue through m.
+func (s *spooler) RegisterMetrics(m Meter) error {
+	items, err := m.Int64ObservableCounter(metricSpoolItems,
+		instrument.WithDescription("payloads spooled to disk, replayed or dropped by the export queue"),
+		instrument.WithUnit("{payload}"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	queued, err := m.Int64ObservableGauge(metricSpoolQueued,
+		instrument.WithDescription("payloads waiting in the export queue"),
+		instrument.WithUnit("{payload}"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	size, err := m.Int64ObservableGauge(metricSpoolSize,
+		instrument.WithDescription("bytes used on disk by the export queue"),
+		instrument.WithUnit("By"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("signal", s.signal)}
+	if s.destination != "" {
+		attrs = append(attrs, attribute.String("destination", s.destination))
+	}
+
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		n, bytes, dropped := s.queue.stats()
+
+		s.mu.Lock()
+		spooled, replayed := s.spooled, s.replayed
+		s.mu.Unlock()
+
+		o.ObserveInt64(items, int64(spooled), append(attrs, attribute.String("state", "spooled"))...)
+		o.ObserveInt64(items, int64(replayed), append(attrs, attribute.String("state", "replayed"))...)
+		o.ObserveInt64(items, int64(dropped), append(attrs, attribute.String("state", "dropped"))...)
+		o.ObserveInt64(queued, int64(n), attrs...)
+		o.ObserveInt64(size, bytes, attrs...)
+		return nil
+	}, items, queued, size)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package otelpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDiskQueue_Order(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openDiskQueue(dir, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []string{"a", "b", "c"} {
+		if err = q.push([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	item, data, ok := q.peek()
+	if !ok || string(data) != "a" {
+		t.Fatalf("peek = %q, %v, want a", data, ok)
+	}
+	q.remove(item)
+
+	// the payloads left on disk are queued again in order
+	q, err = openDiskQueue(dir, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for {
+		item, data, ok = q.peek()
+		if !ok {
+			break
+		}
+		got = append(got, string(data))
+		q.remove(item)
+	}
+
+	if fmt.Sprint(got) != "[b c]" {
+		t.Errorf("replayed %v, want [b c]", got)
+	}
+	if n, size, dropped := q.stats(); n != 0 || size != 0 || dropped != 0 {
+		t.Errorf("stats = %d, %d, %d, want an empty queue without drop", n, size, dropped)
+	}
+}
+
+func TestDiskQueue_Eviction(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	q, err := openDiskQueue(t.TempDir(), 10, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.now = func() time.Time { return now }
+
+	// the oldest payload is dropped above the max size
+	for _, data := range []string{"aaaa", "bbbb", "cccc"} {
+		if err = q.push([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+	if err = q.push(make([]byte, 11)); !errors.Is(err, ErrSpoolItemTooLarge) {
+		t.Errorf("push of 11 bytes: %v, want ErrSpoolItemTooLarge", err)
+	}
+
+	if n, size, dropped := q.stats(); n != 2 || size != 8 || dropped != 2 {
+		t.Errorf("stats = %d, %d, %d, want 2 payloads of 8 bytes and 2 dropped", n, size, dropped)
+	}
+
+	// bbbb is older than the max age, cccc is not
+	now = now.Add(time.Hour - 90*time.Second)
+
+	_, data, ok := q.peek()
+	if !ok || string(data) != "cccc" {
+		t.Errorf("peek = %q, %v, want cccc", data, ok)
+	}
+	if _, _, dropped := q.stats(); dropped != 3 {
+		t.Errorf("dropped = %d, want 3", dropped)
+	}
+}
+
+func TestSpoolTraceClient_Replay(t *testing.T) {
+	next := &fakeTraceClient{errs: []error{
+		status.Error(codes.Unavailable, "collector down"),
+		status.Error(codes.InvalidArgument, "invalid span"),
+		status.Error(codes.Unavailable, "collector down"),
+	}}
+	c := newTestSpoolTraceClient(t, next)
+	ctx := context.Background()
+
+	// transient, spooled
+	if err := c.UploadTraces(ctx, testResourceSpans("first")); err != nil {
+		t.Fatalf("UploadTraces: %v, want the spans spooled", err)
+	}
+	// rejected, returned and not spooled
+	if err := c.UploadTraces(ctx, testResourceSpans("rejected")); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("UploadTraces: %v, want InvalidArgument", err)
+	}
+	if n, _, _ := c.queue.stats(); n != 1 {
+		t.Fatalf("queued = %d, want 1", n)
+	}
+
+	// the collector is still down, the payload stays queued
+	c.replay(ctx)
+	if n, _, _ := c.queue.stats(); n != 1 {
+		t.Fatalf("queued after a failed replay = %d, want 1", n)
+	}
+
+	// the collector recovered
+	c.replay(ctx)
+	if n, _, _ := c.queue.stats(); n != 0 {
+		t.Fatalf("queued after the replay = %d, want 0", n)
+	}
+	if got := next.spanNames(); fmt.Sprint(got) != "[first]" {
+		t.Errorf("uploaded %v, want [first]", got)
+	}
+}
+
+func TestSpoolTraceClient_ReplayHeadOfLine(t *testing.T) {
+	next := &fakeTraceClient{}
+	c := newTestSpoolTraceClient(t, next)
+	ctx := context.Background()
+
+	push := func(data []byte) {
+		if err := c.queue.push(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	marshal := func(name string) []byte {
+		data, err := proto.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: testResourceSpans(name)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	push(marshal("rejected"))
+	push([]byte("not a payload"))
+	push(marshal("last"))
+
+	next.errs = []error{fmt.Errorf("failed to send to http://collector:4318/v1/traces: 400 Bad Request")}
+
+	// the rejected and undecodable payloads do not block the last one
+	c.replay(ctx)
+
+	if n, _, dropped := c.queue.stats(); n != 0 || dropped != 2 {
+		t.Errorf("queued = %d, dropped = %d, want 0 and 2", n, dropped)
+	}
+	if got := next.spanNames(); fmt.Sprint(got) != "[last]" {
+		t.Errorf("uploaded %v, want [last]", got)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, ""), want: true},
+		{name: "grpc wrapped", err: fmt.Errorf("max retry time elapsed: %w", status.Error(codes.DeadlineExceeded, "")), want: true},
+		{name: "grpc invalid argument", err: status.Error(codes.InvalidArgument, ""), want: false},
+		{name: "grpc resource exhausted", err: status.Error(codes.ResourceExhausted, ""), want: false},
+		{name: "http 503", err: &retryableError{err: &httpStatusError{code: 503}}, want: true},
+		{name: "http 502", err: &httpStatusError{code: 502}, want: true},
+		{name: "http 400", err: &httpStatusError{code: 400}, want: false},
+		{name: "sdk http 401", err: errors.New("failed to send to http://collector/v1/traces: 401 Unauthorized"), want: false},
+		{name: "sdk http metrics 504", err: errors.New("failed to send metrics to http://collector/v1/metrics: 504 Gateway Timeout"), want: true},
+		{name: "network", err: &url.Error{Op: "Post", URL: "http://collector", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, want: true},
+		{name: "context", err: context.DeadlineExceeded, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.want {
+			t.Errorf("%s: retryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func newTestSpoolTraceClient(t *testing.T, next *fakeTraceClient) *spoolTraceClient {
+	t.Helper()
+
+	cfg := Config{Logger: logr.Discard()}
+	cfg.spool = &SpoolConfig{Directory: t.TempDir(), RetryInterval: time.Hour}
+
+	c, err := newSpoolTraceClient(next, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = c.Stop(context.Background())
+	})
+
+	return c.(*spoolTraceClient)
+}
+
+func testResourceSpans(name string) []*tracepb.ResourceSpans {
+	return []*tracepb.ResourceSpans{{
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Spans: []*tracepb.Span{{Name: name}},
+		}},
+	}}
+}
+
+// fakeTraceClient fails the uploads with errs, in order, and then records
+// the uploaded spans.
+type fakeTraceClient struct {
+	mu       sync.Mutex
+	errs     []error
+	uploaded []*tracepb.ResourceSpans
+}
+
+func (c *fakeTraceClient) Start(context.Context) error { return nil }
+
+func (c *fakeTraceClient) Stop(context.Context) error { return nil }
+
+func (c *fakeTraceClient) UploadTraces(_ context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return err
+	}
+
+	c.uploaded = append(c.uploaded, protoSpans...)
+	return nil
+}
+
+func (c *fakeTraceClient) spanNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var names []string
+	for _, rs := range c.uploaded {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				names = append(names, s.GetName())
+			}
+		}
+	}
+	return names
+}
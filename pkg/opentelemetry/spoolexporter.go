@@ -0,0 +1,152 @@
+package otelpp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	spoolSignalTraces  = "traces"
+	spoolSignalMetrics = "metrics"
+)
+
+// Compile-time check the spool wrappers implement the SDK interfaces.
+var (
+	_ otlptrace.Client   = (*spoolTraceClient)(nil)
+	_ sdkmetric.Exporter = (*spoolMetricExporter)(nil)
+)
+
+// spoolTraceClient spools the spans that next fails to upload, and replays
+// them through next.
+type spoolTraceClient struct {
+	next otlptrace.Client
+	*spooler
+}
+
+func newSpoolTraceClient(next otlptrace.Client, cfg Config) (otlptrace.Client, error) {
+	c := &spoolTraceClient{next: next}
+
+	s, err := newSpooler(cfg, spoolSignalTraces, c.replayPayload)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+	c.spooler = s
+	c.spooler.start()
+
+	return c, nil
+}
+
+func (c *spoolTraceClient) Start(ctx context.Context) error {
+	return c.next.Start(ctx)
+}
+
+func (c *spoolTraceClient) Stop(ctx context.Context) error {
+	if err := c.close(ctx); err != nil {
+		return err
+	}
+
+	return c.next.Stop(ctx)
+}
+
+func (c *spoolTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	err := c.next.UploadTraces(ctx, protoSpans)
+	if err == nil {
+		return nil
+	}
+
+	return c.spool(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans}, err)
+}
+
+func (c *spoolTraceClient) replayPayload(ctx context.Context, data []byte) error {
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidSpoolPayload, err)
+	}
+
+	return c.next.UploadTraces(ctx, req.ResourceSpans)
+}
+
+func (c *spoolTraceClient) wrapped() []interface{} {
+	return []interface{}{c.next}
+}
+
+// spoolMetricExporter spools the metrics that next fails to export, and
+// replays them through next.
+type spoolMetricExporter struct {
+	next sdkmetric.Exporter
+	*spooler
+}
+
+func newSpoolMetricExporter(next sdkmetric.Exporter, cfg Config) (sdkmetric.Exporter, error) {
+	e := &spoolMetricExporter{next: next}
+
+	var err error
+	e.spooler, err = newSpooler(cfg, spoolSignalMetrics, e.replayPayload)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+	e.spooler.start()
+
+	return e, nil
+}
+
+func (e *spoolMetricExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return e.next.Temporality(k)
+}
+
+func (e *spoolMetricExporter) Aggregation(k sdkmetric.InstrumentKind) aggregation.Aggregation {
+	return e.next.Aggregation(k)
+}
+
+func (e *spoolMetricExporter) Export(ctx context.Context, rm metricdata.ResourceMetrics) error {
+	err := e.next.Export(ctx, rm)
+	if err == nil {
+		return nil
+	}
+
+	return e.spool(&colmetricpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{resourceMetricsToProto(&rm)},
+	}, err)
+}
+
+func (e *spoolMetricExporter) ForceFlush(ctx context.Context) error {
+	return e.next.ForceFlush(ctx)
+}
+
+func (e *spoolMetricExporter) Shutdown(ctx context.Context) error {
+	if err := e.close(ctx); err != nil {
+		return err
+	}
+
+	return e.next.Shutdown(ctx)
+}
+
+func (e *spoolMetricExporter) replayPayload(ctx context.Context, data []byte) error {
+	var req colmetricpb.ExportMetricsServiceRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidSpoolPayload, err)
+	}
+
+	for _, rm := range req.ResourceMetrics {
+		if err := e.next.Export(ctx, resourceMetricsFromProto(rm)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *spoolMetricExporter) wrapped() []interface{} {
+	return []interface{}{e.next}
+}
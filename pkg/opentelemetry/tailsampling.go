@@ -0,0 +1,453 @@
+package otelpp
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultTailDecisionWait     = 10 * time.Second
+	defaultTailMaxTraces        = 10000
+	defaultTailMaxSpansPerTrace = 1000
+
+	metricTailSamplingTraces       = "otelpp.tailsampling.traces"
+	metricTailSamplingDroppedSpans = "otelpp.tailsampling.spans.dropped"
+	metricTailSamplingBuffered     = "otelpp.tailsampling.traces.buffered"
+)
+
+var ErrMissingTailSamplingPolicy = errors.New("missing policies of the tail sampling, no trace would be exported")
+
+// Compile-time check TailSamplingProcessor implements sdktrace.SpanProcessor.
+var _ sdktrace.SpanProcessor = (*TailSamplingProcessor)(nil)
+
+// TailSamplingPolicy returns true when the trace made of spans must be exported.
+type TailSamplingPolicy func(spans []sdktrace.ReadOnlySpan) bool
+
+/*
+TailSamplingConfig - configuration for the TailSamplingProcessor
+DecisionWait - time the spans of a trace are buffered before the decision, default value 10s
+MaxTraces - traces buffered at the same time, the oldest is decided early when full, default value 10000
+MaxSpansPerTrace - spans buffered per trace, the next ones are dropped, default value 1000
+Policies - a trace is exported when any policy returns true, otherwise it is dropped, at least one is required
+*/
+type TailSamplingConfig struct {
+	DecisionWait     time.Duration
+	MaxTraces        int
+	MaxSpansPerTrace int
+	Policies         []TailSamplingPolicy
+}
+
+// ErrorPolicy samples the traces with any span with the error status.
+func ErrorPolicy() TailSamplingPolicy {
+	return func(spans []sdktrace.ReadOnlySpan) bool {
+		for _, s := range spans {
+			if s.Status().Code == codes.Error {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// LatencyPolicy samples the traces whose root span lasts longer than threshold.
+// The whole buffered trace is measured when the root span is not local.
+func LatencyPolicy(threshold time.Duration) TailSamplingPolicy {
+	return func(spans []sdktrace.ReadOnlySpan) bool {
+		var start, end time.Time
+
+		for _, s := range spans {
+			if !s.Parent().IsValid() {
+				return s.EndTime().Sub(s.StartTime()) > threshold
+			}
+			if start.IsZero() || s.StartTime().Before(start) {
+				start = s.StartTime()
+			}
+			if s.EndTime().After(end) {
+				end = s.EndTime()
+			}
+		}
+
+		return end.Sub(start) > threshold
+	}
+}
+
+// AttributePolicy samples the traces with any span with the attribute key
+// equal to one of values, or set to any value when values is empty.
+func AttributePolicy(key string, values ...string) TailSamplingPolicy {
+	k := attribute.Key(key)
+
+	return func(spans []sdktrace.ReadOnlySpan) bool {
+		for _, s := range spans {
+			for _, kv := range s.Attributes() {
+				if kv.Key != k {
+					continue
+				}
+				if len(values) == 0 {
+					return true
+				}
+				for _, v := range values {
+					if kv.Value.Emit() == v {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+}
+
+// ProbabilisticPolicy samples ratio of the traces, clamped to [0, 1]. The
+// decision is derived from the trace ID, like sdktrace.TraceIDRatioBased, so
+// it is consistent between services.
+func ProbabilisticPolicy(ratio float64) TailSamplingPolicy {
+	if ratio > 1 {
+		ratio = 1
+	} else if !(ratio > 0) {
+		ratio = 0
+	}
+	bound := uint64(ratio * (1 << 63))
+
+	return func(spans []sdktrace.ReadOnlySpan) bool {
+		if len(spans) == 0 {
+			return false
+		}
+		id := spans[0].SpanContext().TraceID()
+		return binary.BigEndian.Uint64(id[8:16])>>1 < bound
+	}
+}
+
+type tailTrace struct {
+	spans     []sdktrace.ReadOnlySpan
+	firstSeen time.Time
+}
+
+type tailDecision struct {
+	id      trace.TraceID
+	sampled bool
+	expire  time.Time
+}
+
+/*
+TailSamplingProcessor buffers the ended spans per trace ID for
+DecisionWait and then exports or drops the whole trace according to the
+policies, forwarding the spans of the sampled traces to next.
+
+Only spans sampled by the sampler of the provider reach the processor, so
+it is used with an always on sampler. Spans ended after the decision follow
+the decision of their trace, the decisions are kept for DecisionWait and at
+most MaxTraces of them, the oldest is forgotten first.
+
+When the provider is created with a metric endpoint, the sampled, dropped
+and evicted traces and the dropped spans are exported as metrics.
+*/
+type TailSamplingProcessor struct {
+	next sdktrace.SpanProcessor
+	cfg  TailSamplingConfig
+
+	mu            sync.Mutex
+	traces        map[trace.TraceID]*tailTrace
+	order         []trace.TraceID
+	decisions     map[trace.TraceID]*list.Element
+	decisionOrder *list.List
+
+	sampledTraces uint64
+	droppedTraces uint64
+	evictedTraces uint64
+	droppedSpans  uint64
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	now func() time.Time
+}
+
+// NewTailSamplingProcessor creates a TailSamplingProcessor that forwards the
+// spans of the sampled traces to next, usually a batch span processor. It
+// returns ErrMissingTailSamplingPolicy when cfg has no policy.
+func NewTailSamplingProcessor(next sdktrace.SpanProcessor, cfg TailSamplingConfig) (*TailSamplingProcessor, error) {
+	if len(cfg.Policies) == 0 {
+		return nil, ErrMissingTailSamplingPolicy
+	}
+
+	if cfg.DecisionWait <= 0 {
+		cfg.DecisionWait = defaultTailDecisionWait
+	}
+	if cfg.MaxTraces <= 0 {
+		cfg.MaxTraces = defaultTailMaxTraces
+	}
+	if cfg.MaxSpansPerTrace <= 0 {
+		cfg.MaxSpansPerTrace = defaultTailMaxSpansPerTrace
+	}
+
+	p := &TailSamplingProcessor{
+		next:          next,
+		cfg:           cfg,
+		traces:        make(map[trace.TraceID]*tailTrace),
+		decisions:     make(map[trace.TraceID]*list.Element),
+		decisionOrder: list.New(),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+		now:           time.Now,
+	}
+
+	go p.processTraces()
+
+	return p, nil
+}
+
+// OnStart is called when a span is started.
+func (p *TailSamplingProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd buffers the ended span until the decision of its trace.
+func (p *TailSamplingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+
+	id := s.SpanContext().TraceID()
+
+	p.mu.Lock()
+
+	if e, ok := p.decisions[id]; ok {
+		p.mu.Unlock()
+		if e.Value.(tailDecision).sampled {
+			p.next.OnEnd(s)
+		}
+		return
+	}
+
+	var evicted []sdktrace.ReadOnlySpan
+
+	t, ok := p.traces[id]
+	if !ok {
+		if len(p.order) >= p.cfg.MaxTraces {
+			evicted = p.evictOldest()
+		}
+
+		t = &tailTrace{firstSeen: p.now()}
+		p.traces[id] = t
+		p.order = append(p.order, id)
+	}
+
+	if len(t.spans) >= p.cfg.MaxSpansPerTrace {
+		p.droppedSpans++
+	} else {
+		t.spans = append(t.spans, s)
+	}
+
+	p.mu.Unlock()
+
+	p.export(evicted)
+}
+
+// Shutdown decides the buffered traces and shuts down next.
+func (p *TailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	p.decide(time.Time{})
+
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush decides the buffered traces immediately and flushes next.
+func (p *TailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	p.decide(time.Time{})
+
+	return p.next.ForceFlush(ctx)
+}
+
+// RegisterMetrics exports the trace decisions, the dropped spans and the
+// buffered traces through m.
+func (p *TailSamplingProcessor) RegisterMetrics(m Meter) error {
+	traces, err := m.Int64ObservableCounter(metricTailSamplingTraces,
+		instrument.WithDescription("traces decided by the tail sampling processor"),
+		instrument.WithUnit("{trace}"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	spans, err := m.Int64ObservableCounter(metricTailSamplingDroppedSpans,
+		instrument.WithDescription("spans dropped by the tail sampling processor over the per trace limit"),
+		instrument.WithUnit("{span}"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	buffered, err := m.Int64ObservableGauge(metricTailSamplingBuffered,
+		instrument.WithDescription("traces buffered by the tail sampling processor"),
+		instrument.WithUnit("{trace}"))
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		o.ObserveInt64(traces, int64(p.sampledTraces), attribute.String("decision", "sampled"))
+		o.ObserveInt64(traces, int64(p.droppedTraces), attribute.String("decision", "dropped"))
+		o.ObserveInt64(traces, int64(p.evictedTraces), attribute.String("decision", "evicted"))
+		o.ObserveInt64(spans, int64(p.droppedSpans))
+		o.ObserveInt64(buffered, int64(len(p.traces)))
+		return nil
+	}, traces, spans, buffered)
+	if err != nil {
+		return errors.Wrap(err, err.Error())
+	}
+
+	return nil
+}
+
+func (p *TailSamplingProcessor) processTraces() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.tickInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.decide(p.now())
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *TailSamplingProcessor) tickInterval() time.Duration {
+	interval := p.cfg.DecisionWait / 10
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	return interval
+}
+
+// decide exports the sampled traces buffered for DecisionWait at now, or all
+// the buffered traces when now is zero.
+func (p *TailSamplingProcessor) decide(now time.Time) {
+	var sampled [][]sdktrace.ReadOnlySpan
+
+	p.mu.Lock()
+
+	for len(p.order) > 0 {
+		id := p.order[0]
+		t := p.traces[id]
+		if !now.IsZero() && now.Sub(t.firstSeen) < p.cfg.DecisionWait {
+			break
+		}
+
+		p.order = p.order[1:]
+		delete(p.traces, id)
+
+		ok := p.sample(t.spans)
+		if ok {
+			p.sampledTraces++
+			sampled = append(sampled, t.spans)
+		} else {
+			p.droppedTraces++
+		}
+		p.addDecision(id, ok)
+	}
+
+	p.expireDecisions()
+
+	p.mu.Unlock()
+
+	for _, spans := range sampled {
+		p.export(spans)
+	}
+}
+
+// evictOldest decides the oldest buffered trace to release memory and
+// returns its spans when sampled. It must be called holding mu.
+func (p *TailSamplingProcessor) evictOldest() []sdktrace.ReadOnlySpan {
+	id := p.order[0]
+	t := p.traces[id]
+
+	p.order = p.order[1:]
+	delete(p.traces, id)
+	p.evictedTraces++
+
+	ok := p.sample(t.spans)
+	p.addDecision(id, ok)
+
+	if !ok {
+		return nil
+	}
+
+	return t.spans
+}
+
+// addDecision records the decision of the trace id, forgetting the oldest
+// decision above MaxTraces. It must be called holding mu.
+func (p *TailSamplingProcessor) addDecision(id trace.TraceID, sampled bool) {
+	if e, ok := p.decisions[id]; ok {
+		p.removeDecision(e)
+	}
+
+	p.decisions[id] = p.decisionOrder.PushBack(tailDecision{
+		id:      id,
+		sampled: sampled,
+		expire:  p.now().Add(p.cfg.DecisionWait),
+	})
+
+	if p.decisionOrder.Len() > p.cfg.MaxTraces {
+		p.removeDecision(p.decisionOrder.Front())
+	}
+}
+
+// expireDecisions forgets the decisions older than DecisionWait, they are
+// ordered by expiry so only the expired ones are visited. It must be called
+// holding mu.
+func (p *TailSamplingProcessor) expireDecisions() {
+	now := p.now()
+
+	for e := p.decisionOrder.Front(); e != nil; e = p.decisionOrder.Front() {
+		if !now.After(e.Value.(tailDecision).expire) {
+			return
+		}
+		p.removeDecision(e)
+	}
+}
+
+func (p *TailSamplingProcessor) removeDecision(e *list.Element) {
+	p.decisionOrder.Remove(e)
+	delete(p.decisions, e.Value.(tailDecision).id)
+}
+
+func (p *TailSamplingProcessor) sample(spans []sdktrace.ReadOnlySpan) bool {
+	for _, policy := range p.cfg.Policies {
+		if policy(spans) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *TailSamplingProcessor) export(spans []sdktrace.ReadOnlySpan) {
+	for _, s := range spans {
+		p.next.OnEnd(s)
+	}
+}
@@ -0,0 +1,266 @@
+package otelpp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTailSamplingPolicies(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	root := testSpanContext(1, 1)
+	child := testSpanContext(1, 2)
+
+	tests := []struct {
+		name   string
+		policy TailSamplingPolicy
+		spans  tracetest.SpanStubs
+		want   bool
+	}{
+		{
+			name:   "error",
+			policy: ErrorPolicy(),
+			spans:  tracetest.SpanStubs{{SpanContext: child, Parent: root, Status: sdktrace.Status{Code: codes.Error}}},
+			want:   true,
+		},
+		{
+			name:   "no error",
+			policy: ErrorPolicy(),
+			spans:  tracetest.SpanStubs{{SpanContext: root, Status: sdktrace.Status{Code: codes.Ok}}},
+			want:   false,
+		},
+		{
+			name:   "slow root span",
+			policy: LatencyPolicy(time.Second),
+			spans:  tracetest.SpanStubs{{SpanContext: root, StartTime: start, EndTime: start.Add(2 * time.Second)}},
+			want:   true,
+		},
+		{
+			name:   "fast root span with a slow remote trace",
+			policy: LatencyPolicy(time.Second),
+			spans: tracetest.SpanStubs{
+				{SpanContext: child, Parent: root, StartTime: start, EndTime: start.Add(3 * time.Second)},
+				{SpanContext: root, StartTime: start, EndTime: start.Add(time.Millisecond)},
+			},
+			want: false,
+		},
+		{
+			name:   "slow trace without local root span",
+			policy: LatencyPolicy(time.Second),
+			spans: tracetest.SpanStubs{
+				{SpanContext: child, Parent: root, StartTime: start, EndTime: start.Add(time.Millisecond)},
+				{SpanContext: testSpanContext(1, 3), Parent: root, StartTime: start.Add(time.Second), EndTime: start.Add(2 * time.Second)},
+			},
+			want: true,
+		},
+		{
+			name:   "attribute value",
+			policy: AttributePolicy("tenant", "a", "b"),
+			spans:  tracetest.SpanStubs{{SpanContext: root, Attributes: []attribute.KeyValue{attribute.String("tenant", "b")}}},
+			want:   true,
+		},
+		{
+			name:   "other attribute value",
+			policy: AttributePolicy("tenant", "a"),
+			spans:  tracetest.SpanStubs{{SpanContext: root, Attributes: []attribute.KeyValue{attribute.String("tenant", "c")}}},
+			want:   false,
+		},
+		{
+			name:   "attribute set",
+			policy: AttributePolicy("debug"),
+			spans:  tracetest.SpanStubs{{SpanContext: root, Attributes: []attribute.KeyValue{attribute.Bool("debug", false)}}},
+			want:   true,
+		},
+		{
+			name:   "probability 0",
+			policy: ProbabilisticPolicy(0),
+			spans:  tracetest.SpanStubs{{SpanContext: testSpanContext(^uint64(0)>>1, 1)}},
+			want:   false,
+		},
+		{
+			name:   "probability 1",
+			policy: ProbabilisticPolicy(1),
+			spans:  tracetest.SpanStubs{{SpanContext: testSpanContext(^uint64(0), 1)}},
+			want:   true,
+		},
+		{
+			name:   "probability above 1 clamped",
+			policy: ProbabilisticPolicy(2),
+			spans:  tracetest.SpanStubs{{SpanContext: testSpanContext(^uint64(0), 1)}},
+			want:   true,
+		},
+		{
+			name:   "probability below 0 clamped",
+			policy: ProbabilisticPolicy(-1),
+			spans:  tracetest.SpanStubs{{SpanContext: testSpanContext(0, 1)}},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy(tt.spans.Snapshots()); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTailSamplingProcessor_LateSpans(t *testing.T) {
+	p, next, now := newTestTailSamplingProcessor(t, TailSamplingConfig{})
+
+	p.OnEnd(testTailSpan(1, 1, "sampled", true))
+	p.OnEnd(testTailSpan(2, 1, "dropped", false))
+
+	// not decided before DecisionWait
+	*now = now.Add(time.Minute)
+	p.decide(*now)
+	if got := endedSpanNames(next); len(got) != 0 {
+		t.Fatalf("exported %v before DecisionWait", got)
+	}
+
+	*now = now.Add(time.Hour)
+	p.decide(*now)
+	if got := endedSpanNames(next); fmt.Sprint(got) != "[sampled]" {
+		t.Fatalf("exported %v, want [sampled]", got)
+	}
+
+	// the spans ended after the decision follow it
+	p.OnEnd(testTailSpan(1, 2, "sampled late", false))
+	p.OnEnd(testTailSpan(2, 2, "dropped late", true))
+
+	if got := endedSpanNames(next); fmt.Sprint(got) != "[sampled sampled late]" {
+		t.Errorf("exported %v, want [sampled sampled late]", got)
+	}
+}
+
+func TestTailSamplingProcessor_Eviction(t *testing.T) {
+	p, next, _ := newTestTailSamplingProcessor(t, TailSamplingConfig{MaxTraces: 2, MaxSpansPerTrace: 1})
+
+	p.OnEnd(testTailSpan(1, 1, "first", true))
+	p.OnEnd(testTailSpan(1, 2, "over the span limit", true))
+	p.OnEnd(testTailSpan(2, 1, "second", false))
+
+	// the oldest trace is decided early when a third one is buffered
+	p.OnEnd(testTailSpan(3, 1, "third", true))
+
+	if got := endedSpanNames(next); fmt.Sprint(got) != "[first]" {
+		t.Errorf("exported %v, want [first]", got)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.traces) != 2 || len(p.order) != 2 {
+		t.Errorf("buffered %d traces, want 2", len(p.traces))
+	}
+	if p.evictedTraces != 1 || p.droppedSpans != 1 {
+		t.Errorf("evicted = %d, dropped spans = %d, want 1 and 1", p.evictedTraces, p.droppedSpans)
+	}
+}
+
+func TestTailSamplingProcessor_Decisions(t *testing.T) {
+	p, _, now := newTestTailSamplingProcessor(t, TailSamplingConfig{MaxTraces: 2})
+
+	for i := uint64(1); i <= 3; i++ {
+		p.OnEnd(testTailSpan(i, 1, "span", true))
+		*now = now.Add(time.Minute)
+	}
+	p.decide(time.Time{})
+
+	// the decisions are capped by MaxTraces, the oldest is forgotten
+	p.mu.Lock()
+	ids := decisionTraceIDs(p)
+	p.mu.Unlock()
+	if fmt.Sprint(ids) != "[2 3]" {
+		t.Fatalf("decisions of %v, want [2 3]", ids)
+	}
+
+	// and expire after DecisionWait
+	*now = now.Add(time.Hour + time.Second)
+	p.decide(*now)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.decisions) != 0 || p.decisionOrder.Len() != 0 {
+		t.Errorf("decisions of %v, want them expired", decisionTraceIDs(p))
+	}
+}
+
+func TestNewTailSamplingProcessor_MissingPolicy(t *testing.T) {
+	if _, err := NewTailSamplingProcessor(tracetest.NewSpanRecorder(), TailSamplingConfig{}); err != ErrMissingTailSamplingPolicy {
+		t.Errorf("err = %v, want ErrMissingTailSamplingPolicy", err)
+	}
+}
+
+// newTestTailSamplingProcessor returns a processor sampling the spans with
+// the sample attribute, with a DecisionWait of one hour by default so the
+// decisions are only taken by the test, at the time it sets.
+func newTestTailSamplingProcessor(t *testing.T, cfg TailSamplingConfig) (*TailSamplingProcessor, *tracetest.SpanRecorder, *time.Time) {
+	t.Helper()
+
+	if cfg.DecisionWait == 0 {
+		cfg.DecisionWait = time.Hour
+	}
+	cfg.Policies = []TailSamplingPolicy{AttributePolicy("sample", "true")}
+
+	next := tracetest.NewSpanRecorder()
+	p, err := NewTailSamplingProcessor(next, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = p.Shutdown(context.Background())
+	})
+
+	now := time.Unix(1_700_000_000, 0)
+	p.mu.Lock()
+	p.now = func() time.Time { return now }
+	p.mu.Unlock()
+
+	return p, next, &now
+}
+
+func testTailSpan(traceID, spanID uint64, name string, sample bool) sdktrace.ReadOnlySpan {
+	return tracetest.SpanStub{
+		Name:        name,
+		SpanContext: testSpanContext(traceID, spanID),
+		Attributes:  []attribute.KeyValue{attribute.Bool("sample", sample)},
+	}.Snapshot()
+}
+
+func testSpanContext(traceID, spanID uint64) trace.SpanContext {
+	var tid trace.TraceID
+	var sid trace.SpanID
+	for i := 0; i < 8; i++ {
+		tid[15-i] = byte(traceID >> (8 * i))
+		sid[7-i] = byte(spanID >> (8 * i))
+	}
+
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, TraceFlags: trace.FlagsSampled})
+}
+
+func endedSpanNames(r *tracetest.SpanRecorder) []string {
+	var names []string
+	for _, s := range r.Ended() {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// decisionTraceIDs returns the low bytes of the decided trace IDs. It must be
+// called holding mu.
+func decisionTraceIDs(p *TailSamplingProcessor) []int {
+	var ids []int
+	for id := range p.decisions {
+		ids = append(ids, int(id[15]))
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,95 @@
+package otelpp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// TemporalityPreference is a preset of the temporality of the exported
+// metrics per instrument kind, as defined for
+// OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE.
+type TemporalityPreference string
+
+const (
+	// TemporalityCumulative exports all the instruments as cumulative, the
+	// default of the SDK.
+	TemporalityCumulative TemporalityPreference = "cumulative"
+	// TemporalityDelta exports the counters and the histograms as delta, the
+	// up down counters stay cumulative.
+	TemporalityDelta TemporalityPreference = "delta"
+	// TemporalityLowMemory exports the synchronous counters and the
+	// histograms as delta, the others stay cumulative to avoid keeping the
+	// previous observations in memory.
+	TemporalityLowMemory TemporalityPreference = "lowmemory"
+)
+
+var ErrInvalidTemporalityPreference = errors.New("invalid temporality preference, use one of: cumulative, delta, lowmemory")
+
+// TemporalityPreferenceFromString translates a string to a TemporalityPreference, it is case-insensitive
+func TemporalityPreferenceFromString(preference string) (TemporalityPreference, error) {
+	p := TemporalityPreference(strings.ToLower(strings.TrimSpace(preference)))
+	if p.Selector() == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTemporalityPreference, preference)
+	}
+	return p, nil
+}
+
+// Selector returns the temporality selector of the preference, nil when the
+// preference is invalid.
+func (p TemporalityPreference) Selector() sdkmetric.TemporalitySelector {
+	switch p {
+	case TemporalityCumulative:
+		return sdkmetric.DefaultTemporalitySelector
+	case TemporalityDelta:
+		return deltaTemporalitySelector
+	case TemporalityLowMemory:
+		return lowMemoryTemporalitySelector
+	}
+	return nil
+}
+
+// String used to translate a TemporalityPreference to string
+func (p TemporalityPreference) String() string {
+	return string(p)
+}
+
+func deltaTemporalitySelector(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	switch k {
+	case sdkmetric.InstrumentKindCounter,
+		sdkmetric.InstrumentKindHistogram,
+		sdkmetric.InstrumentKindObservableCounter:
+		return metricdata.DeltaTemporality
+	}
+	return metricdata.CumulativeTemporality
+}
+
+func lowMemoryTemporalitySelector(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	switch k {
+	case sdkmetric.InstrumentKindCounter,
+		sdkmetric.InstrumentKindHistogram:
+		return metricdata.DeltaTemporality
+	}
+	return metricdata.CumulativeTemporality
+}
+
+// temporalitySelector returns the selector of WithTemporalitySelector, or
+// the cumulative default of the SDK.
+func (c *Config) temporalitySelector() sdkmetric.TemporalitySelector {
+	if c.MetricConfig.temporality != nil {
+		return c.MetricConfig.temporality
+	}
+	return sdkmetric.DefaultTemporalitySelector
+}
+
+// aggregationSelector returns the selector of WithAggregationSelector, or
+// the default of the SDK.
+func (c *Config) aggregationSelector() sdkmetric.AggregationSelector {
+	if c.MetricConfig.aggregation != nil {
+		return c.MetricConfig.aggregation
+	}
+	return sdkmetric.DefaultAggregationSelector
+}
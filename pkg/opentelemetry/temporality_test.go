@@ -0,0 +1,118 @@
+package otelpp
+
+import (
+	"errors"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestTemporalityPreference_Selector(t *testing.T) {
+	const (
+		cumulative = metricdata.CumulativeTemporality
+		delta      = metricdata.DeltaTemporality
+	)
+
+	kinds := []sdkmetric.InstrumentKind{
+		sdkmetric.InstrumentKindCounter,
+		sdkmetric.InstrumentKindUpDownCounter,
+		sdkmetric.InstrumentKindHistogram,
+		sdkmetric.InstrumentKindObservableCounter,
+		sdkmetric.InstrumentKindObservableUpDownCounter,
+		sdkmetric.InstrumentKindObservableGauge,
+	}
+
+	tests := []struct {
+		preference TemporalityPreference
+		want       []metricdata.Temporality
+	}{
+		{TemporalityCumulative, []metricdata.Temporality{cumulative, cumulative, cumulative, cumulative, cumulative, cumulative}},
+		{TemporalityDelta, []metricdata.Temporality{delta, cumulative, delta, delta, cumulative, cumulative}},
+		{TemporalityLowMemory, []metricdata.Temporality{delta, cumulative, delta, cumulative, cumulative, cumulative}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preference.String(), func(t *testing.T) {
+			selector := tt.preference.Selector()
+			for i, k := range kinds {
+				if got := selector(k); got != tt.want[i] {
+					t.Errorf("temporality of kind %d = %v, want %v", k, got, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if TemporalityPreference("gauge").Selector() != nil {
+		t.Error("selector of an invalid preference, want nil")
+	}
+}
+
+func TestTemporalityPreferenceFromString(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TemporalityPreference
+		wantErr error
+	}{
+		{in: "cumulative", want: TemporalityCumulative},
+		{in: " Delta ", want: TemporalityDelta},
+		{in: "LOWMEMORY", want: TemporalityLowMemory},
+		{in: "", wantErr: ErrInvalidTemporalityPreference},
+		{in: "gauge", wantErr: ErrInvalidTemporalityPreference},
+	}
+
+	for _, tt := range tests {
+		got, err := TemporalityPreferenceFromString(tt.in)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("TemporalityPreferenceFromString(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTemporalitySelector_Env(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		opts []OptionProvider
+		want metricdata.Temporality
+	}{
+		{name: "default", want: metricdata.CumulativeTemporality},
+		{name: "environment", env: "delta", want: metricdata.DeltaTemporality},
+		{name: "invalid environment", env: "gauge", want: metricdata.CumulativeTemporality},
+		{
+			name: "option over environment",
+			env:  "delta",
+			opts: []OptionProvider{WithMetric(WithTemporalityPreference(TemporalityCumulative))},
+			want: metricdata.CumulativeTemporality,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearOtelEnv(t)
+			t.Setenv(envMetricTemporality, tt.env)
+
+			cfg := buildConfig(tt.opts...)
+			if got := cfg.temporalitySelector()(sdkmetric.InstrumentKindCounter); got != tt.want {
+				t.Errorf("temporality of a counter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregationSelector(t *testing.T) {
+	clearOtelEnv(t)
+
+	cfg := buildConfig()
+	if _, ok := cfg.aggregationSelector()(sdkmetric.InstrumentKindHistogram).(aggregation.ExplicitBucketHistogram); !ok {
+		t.Error("default aggregation of a histogram, want the explicit bucket histogram of the SDK")
+	}
+
+	cfg = buildConfig(WithMetric(WithAggregationSelector(func(sdkmetric.InstrumentKind) aggregation.Aggregation {
+		return aggregation.Drop{}
+	})))
+	if _, ok := cfg.aggregationSelector()(sdkmetric.InstrumentKindHistogram).(aggregation.Drop); !ok {
+		t.Error("aggregation of a histogram, want the one of WithAggregationSelector")
+	}
+}
@@ -0,0 +1,219 @@
+package otelpp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidCACert = errors.New("no valid certificate found in CA file")
+
+// TLSConfig contains the fields used to build the client TLS configuration
+// shared by the gRPC and HTTP exporters.
+// CACertFile and the client certificate files are read again whenever
+// their modification time changes, so rotated certificates are picked up
+// on the next handshake without restarting the application.
+// The server certificate is verified against ServerName, or the host of the
+// endpoint when not set.
+type TLSConfig struct {
+	tlsConfig      *tls.Config
+	CACertFile     string
+	ClientCertFile string
+	ClientKeyFile  string
+	ServerName     string
+}
+
+func (c *TLSConfig) tlsEnable() bool {
+	return c.tlsConfig != nil ||
+		c.CACertFile != "" ||
+		c.ClientCertFile != "" ||
+		c.ServerName != ""
+}
+
+// createTLSConfig builds the *tls.Config used by the exporters of endpoint.
+// The base configuration set with WithTLSConfig is cloned, so it is never
+// mutated.
+func createTLSConfig(cfg Config, endpoint string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.tlsConfig != nil {
+		tlsCfg = cfg.tlsConfig.Clone()
+	}
+
+	if cfg.ServerName != "" {
+		tlsCfg.ServerName = cfg.ServerName
+	}
+
+	if cfg.CACertFile == "" && cfg.ClientCertFile == "" {
+		return tlsCfg, nil
+	}
+
+	reloader := &certReloader{
+		caFile:   cfg.CACertFile,
+		certFile: cfg.ClientCertFile,
+		keyFile:  cfg.ClientKeyFile,
+	}
+
+	if reloader.caFile != "" {
+		if _, err := reloader.rootCAs(); err != nil {
+			return nil, errors.Wrap(err, "failed to load CA certificate")
+		}
+		// Verification is done by verifyConnection so the CA pool can be
+		// reloaded; the standard verification would pin the pool forever.
+		// The server name is resolved now, the one of the connection state
+		// is the SNI, empty for an IP endpoint.
+		reloader.serverName = expectedServerName(tlsCfg, endpoint)
+		reloader.next = tlsCfg.VerifyConnection
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyConnection = reloader.verifyConnection
+	}
+
+	if reloader.certFile != "" {
+		if _, err := reloader.clientCertificate(nil); err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate")
+		}
+		tlsCfg.Certificates = nil
+		tlsCfg.GetClientCertificate = reloader.clientCertificate
+	}
+
+	return tlsCfg, nil
+}
+
+// expectedServerName returns the name the server certificate is verified
+// against: the ServerName of the configuration, set by WithTLSServerName or
+// WithTLSConfig, otherwise the host of endpoint, which may be an IP checked
+// against the IP SANs.
+func expectedServerName(tlsCfg *tls.Config, endpoint string) string {
+	if tlsCfg.ServerName != "" {
+		return tlsCfg.ServerName
+	}
+
+	host, _, _ := strings.Cut(trimEndpoint(endpoint), "/")
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.Trim(host, "[]")
+}
+
+// certReloader keeps the CA pool and the client certificate in memory and
+// reloads them from disk when the files change.
+type certReloader struct {
+	mu sync.Mutex
+
+	caFile     string
+	caModTime  time.Time
+	pool       *x509.CertPool
+	serverName string
+	next       func(tls.ConnectionState) error
+
+	certFile    string
+	keyFile     string
+	certModTime time.Time
+	cert        *tls.Certificate
+}
+
+func (r *certReloader) rootCAs() (*x509.CertPool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTime, err := fileModTime(r.caFile)
+	if err != nil {
+		return nil, err
+	}
+	if r.pool != nil && modTime.Equal(r.caModTime) {
+		return r.pool, nil
+	}
+
+	pem, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, ErrInvalidCACert
+	}
+
+	r.pool = pool
+	r.caModTime = modTime
+
+	return r.pool, nil
+}
+
+func (r *certReloader) clientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certModTime, err := fileModTime(r.certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyModTime, err := fileModTime(r.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if keyModTime.After(certModTime) {
+		certModTime = keyModTime
+	}
+	if r.cert != nil && certModTime.Equal(r.certModTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, err.Error())
+	}
+
+	r.cert = &cert
+	r.certModTime = certModTime
+
+	return r.cert, nil
+}
+
+func (r *certReloader) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no peer certificate presented by the server")
+	}
+
+	pool, err := r.rootCAs()
+	if err != nil {
+		return err
+	}
+
+	if r.serverName == "" {
+		return errors.New("no server name to verify the server certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		DNSName:       r.serverName,
+		Roots:         pool,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if _, err = cs.PeerCertificates[0].Verify(opts); err != nil {
+		return err
+	}
+
+	if r.next != nil {
+		return r.next(cs)
+	}
+
+	return nil
+}
+
+func fileModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, err.Error())
+	}
+	return info.ModTime(), nil
+}
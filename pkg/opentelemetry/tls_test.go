@@ -0,0 +1,348 @@
+package otelpp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a certificate authority issuing the certificates of the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "otelpp test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a certificate for the DNS names and the IPs, and its key, in
+// PEM.
+func (ca *testCA) issue(t *testing.T, dnsNames []string, ips []net.IP, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "otelpp test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startTLSServer accepts the connections on 127.0.0.1 with the server
+// certificate, and returns its address.
+func startTLSServer(t *testing.T, certPEM, keyPEM []byte, clientCAs *x509.CertPool) string {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if clientCAs != nil {
+		cfg.ClientCAs = clientCAs
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if conn.(*tls.Conn).Handshake() == nil {
+					_, _ = conn.Write([]byte{1})
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+// handshake connects to addr with the client configuration of cfg.
+func handshake(t *testing.T, cfg Config, addr string) error {
+	t.Helper()
+
+	tlsCfg, err := createTLSConfig(cfg, addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, tlsCfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// the server verifies the client certificate after the client finished
+	// its handshake, it writes a byte when the handshake succeeded
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+
+	return err
+}
+
+func TestCreateTLSConfig_VerifiesIPEndpointAgainstIPSANs(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	certPEM, keyPEM := ca.issue(t, nil, []net.IP{net.ParseIP("127.0.0.1")}, x509.ExtKeyUsageServerAuth)
+	addr := startTLSServer(t, certPEM, keyPEM, nil)
+
+	cfg := Config{OtlpConfig: OtlpConfig{TLSConfig: TLSConfig{CACertFile: caFile}}}
+	if err := handshake(t, cfg, addr); err != nil {
+		t.Fatalf("handshake with a certificate for the endpoint IP: %v", err)
+	}
+}
+
+func TestCreateTLSConfig_RejectsCertificateOfAnotherHost(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	// signed by the trusted CA, but for another host than the endpoint
+	certPEM, keyPEM := ca.issue(t, []string{"other.example"}, nil, x509.ExtKeyUsageServerAuth)
+	addr := startTLSServer(t, certPEM, keyPEM, nil)
+
+	cfg := Config{OtlpConfig: OtlpConfig{TLSConfig: TLSConfig{CACertFile: caFile}}}
+	err := handshake(t, cfg, addr)
+
+	var hostErr x509.HostnameError
+	if !errors.As(err, &hostErr) {
+		t.Fatalf("handshake error = %v, want a x509.HostnameError", err)
+	}
+}
+
+func TestCreateTLSConfig_ServerNameOverridesEndpointHost(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	certPEM, keyPEM := ca.issue(t, []string{"collector.internal"}, nil, x509.ExtKeyUsageServerAuth)
+	addr := startTLSServer(t, certPEM, keyPEM, nil)
+
+	cfg := Config{OtlpConfig: OtlpConfig{TLSConfig: TLSConfig{CACertFile: caFile, ServerName: "collector.internal"}}}
+	if err := handshake(t, cfg, addr); err != nil {
+		t.Fatalf("handshake with WithTLSServerName: %v", err)
+	}
+
+	cfg.ServerName = "wrong.internal"
+	if err := handshake(t, cfg, addr); err == nil {
+		t.Fatal("handshake with a wrong server name succeeded")
+	}
+}
+
+func TestCreateTLSConfig_ReloadsRotatedCA(t *testing.T) {
+	oldCA, newCA := newTestCA(t), newTestCA(t)
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, oldCA.pem, time.Now().Add(-time.Minute))
+
+	// the server already uses a certificate of the new CA
+	certPEM, keyPEM := newCA.issue(t, nil, []net.IP{net.ParseIP("127.0.0.1")}, x509.ExtKeyUsageServerAuth)
+	addr := startTLSServer(t, certPEM, keyPEM, nil)
+
+	cfg := Config{OtlpConfig: OtlpConfig{TLSConfig: TLSConfig{CACertFile: caFile}}}
+	tlsCfg, err := createTLSConfig(cfg, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dial := func() error {
+		conn, err := tls.Dial("tcp", addr, tlsCfg)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	if err = dial(); err == nil {
+		t.Fatal("handshake succeeded with the old CA")
+	}
+
+	writeFile(t, caFile, newCA.pem, time.Now())
+
+	if err = dial(); err != nil {
+		t.Fatalf("handshake after the CA rotation: %v", err)
+	}
+}
+
+func TestCreateTLSConfig_ClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	clientCert, clientKey := ca.issue(t, nil, nil, x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, clientCert, time.Now())
+	writeFile(t, keyFile, clientKey, time.Now())
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	serverCert, serverKey := ca.issue(t, nil, []net.IP{net.ParseIP("127.0.0.1")}, x509.ExtKeyUsageServerAuth)
+	addr := startTLSServer(t, serverCert, serverKey, pool)
+
+	cfg := Config{OtlpConfig: OtlpConfig{TLSConfig: TLSConfig{
+		CACertFile:     caFile,
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+	}}}
+	if err := handshake(t, cfg, addr); err != nil {
+		t.Fatalf("mTLS handshake: %v", err)
+	}
+
+	cfg.ClientCertFile, cfg.ClientKeyFile = "", ""
+	if err := handshake(t, cfg, addr); err == nil {
+		t.Fatal("handshake without client certificate accepted by a server requiring it")
+	}
+}
+
+func TestCreateTLSConfig_CallsVerifyConnectionOfWithTLSConfig(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	certPEM, keyPEM := ca.issue(t, nil, []net.IP{net.ParseIP("127.0.0.1")}, x509.ExtKeyUsageServerAuth)
+	addr := startTLSServer(t, certPEM, keyPEM, nil)
+
+	errRejected := errors.New("rejected by the application")
+	called := false
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		VerifyConnection: func(tls.ConnectionState) error {
+			called = true
+			return errRejected
+		},
+	}
+
+	cfg := Config{OtlpConfig: OtlpConfig{TLSConfig: TLSConfig{tlsConfig: base, CACertFile: caFile}}}
+	err := handshake(t, cfg, addr)
+
+	if !called {
+		t.Fatal("VerifyConnection of WithTLSConfig not called")
+	}
+	if err == nil {
+		t.Fatal("handshake succeeded although VerifyConnection of WithTLSConfig failed")
+	}
+}
+
+func TestExpectedServerName(t *testing.T) {
+	tests := []struct {
+		serverName string
+		endpoint   string
+		want       string
+	}{
+		{"", "10.0.0.5:4317", "10.0.0.5"},
+		{"", "collector:4318", "collector"},
+		{"", "http://collector:4318", "collector"},
+		{"", "https://collector:4318", "collector"},
+		{"", "https://collector:4318/otlp", "collector"},
+		{"", "HTTPS://collector", "collector"},
+		{"", "[::1]:4317", "::1"},
+		{"", "https://[2001:db8::5]:4318", "2001:db8::5"},
+		{"", "2001:db8::5", "2001:db8::5"},
+		{"", "collector", "collector"},
+		{"collector.internal", "10.0.0.5:4317", "collector.internal"},
+	}
+
+	for _, tt := range tests {
+		got := expectedServerName(&tls.Config{ServerName: tt.serverName}, tt.endpoint)
+		if got != tt.want {
+			t.Errorf("expectedServerName(%q, %q) = %q, want %q", tt.serverName, tt.endpoint, got, tt.want)
+		}
+	}
+}
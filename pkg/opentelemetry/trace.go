@@ -1,14 +1,11 @@
 package otelpp
 
-//go:generate mockgen -destination=./mocks/mock_telemetry.go -package=mocks github.com/PicPay/lib-go-otel/v2 Telemetry
-//go:generate mockgen -destination=./mocks/mock_span.go -package=mocks github.com/PicPay/lib-go-otel/v2 Span
-
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -25,8 +22,19 @@ type Span interface {
 
 // Tracing is the structure to be used for handling OTel traces.
 type Tracing struct {
-	provider *sdktrace.TracerProvider
-	tracer   trace.Tracer
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	components []interface{}
+}
+
+// NewTracing returns the Tracing of tp, creating the spans with the tracer
+// name. NewProvider should be used instead, unless the provider is built by
+// the caller, as done by otelpptest.
+func NewTracing(tp *sdktrace.TracerProvider, name string) *Tracing {
+	return &Tracing{
+		provider: tp,
+		tracer:   tp.Tracer(name),
+	}
 }
 
 /*
@@ -39,30 +47,74 @@ func (t *Tracing) Start(ctx context.Context, name string, opts ...trace.SpanStar
 	return t.tracer.Start(ctx, name, opts...)
 }
 
+// ForceFlush exports the ended spans waiting in the span processors,
+// returning the error of the exporter.
+func (t *Tracing) ForceFlush(ctx context.Context) error {
+	return t.provider.ForceFlush(ctx)
+}
+
 // Shutdown shuts down the span processors in the order they were registered.
 func (t *Tracing) Shutdown(ctx context.Context) error {
 	return t.provider.Shutdown(ctx)
 }
 
-func createResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
-	return resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceNameKey.String(cfg.ServiceName),
-			semconv.DeploymentEnvironmentKey.String(cfg.AppEnv.String()),
-		),
-	)
+// traceExporter is the OTLP exporter of client, exposing the components of
+// the client.
+type traceExporter struct {
+	sdktrace.SpanExporter
+	client otlptrace.Client
+}
+
+func (e *traceExporter) wrapped() []interface{} {
+	return []interface{}{e.client}
+}
+
+// createSpanProcessor returns next behind a TailSamplingProcessor when tail
+// sampling is set.
+func createSpanProcessor(next sdktrace.SpanProcessor, cfg Config) (sdktrace.SpanProcessor, error) {
+	if cfg.tailSampling == nil {
+		return next, nil
+	}
+
+	p, err := NewTailSamplingProcessor(next, *cfg.tailSampling)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
 }
 
-func createTracerProvider(e sdktrace.SpanExporter, r *resource.Resource, cfg Config) (*sdktrace.TracerProvider, error) {
+func createBatchSpanProcessor(e sdktrace.SpanExporter, cfg Config) sdktrace.SpanProcessor {
 	var opts []sdktrace.BatchSpanProcessorOption
 
 	if cfg.sendIntervalTrace != nil {
 		opts = append(opts, sdktrace.WithBatchTimeout(*cfg.sendIntervalTrace))
 	}
 
+	if cfg.exportTimeoutTrace != nil {
+		opts = append(opts, sdktrace.WithExportTimeout(*cfg.exportTimeoutTrace))
+	}
+
+	if cfg.maxQueueSizeTrace > 0 {
+		opts = append(opts, sdktrace.WithMaxQueueSize(cfg.maxQueueSizeTrace))
+	}
+
+	if cfg.maxExportBatchSizeTrace > 0 {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(cfg.maxExportBatchSizeTrace))
+	}
+
+	return sdktrace.NewBatchSpanProcessor(e, opts...)
+}
+
+func createTracerProvider(sp sdktrace.SpanProcessor, r *resource.Resource, cfg Config) (*sdktrace.TracerProvider, error) {
+	sampler, err := createSampler(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithBatcher(e, opts...),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(sp),
 		sdktrace.WithResource(r),
 	), nil
 }
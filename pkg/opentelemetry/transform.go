@@ -0,0 +1,405 @@
+package otelpp
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// The functions in this file translate SDK data into its OTLP protobuf
+// representation, for the exporters that are not provided by the SDK, and
+// the metrics back into SDK data, to replay the spooled metrics through the
+// exporter of the provider.
+
+func resourceToProto(r *resource.Resource) *resourcepb.Resource {
+	if r == nil {
+		return &resourcepb.Resource{}
+	}
+	return &resourcepb.Resource{Attributes: attributesToProto(r.Attributes())}
+}
+
+func scopeToProto(s instrumentation.Scope) *commonpb.InstrumentationScope {
+	return &commonpb.InstrumentationScope{
+		Name:    s.Name,
+		Version: s.Version,
+	}
+}
+
+func attributesToProto(attrs []attribute.KeyValue) []*commonpb.KeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	out := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		out = append(out, &commonpb.KeyValue{
+			Key:   string(kv.Key),
+			Value: attributeValueToProto(kv.Value),
+		})
+	}
+	return out
+}
+
+func attributeValueToProto(v attribute.Value) *commonpb.AnyValue {
+	av := &commonpb.AnyValue{}
+
+	switch v.Type() {
+	case attribute.BOOL:
+		av.Value = &commonpb.AnyValue_BoolValue{BoolValue: v.AsBool()}
+	case attribute.INT64:
+		av.Value = &commonpb.AnyValue_IntValue{IntValue: v.AsInt64()}
+	case attribute.FLOAT64:
+		av.Value = &commonpb.AnyValue_DoubleValue{DoubleValue: v.AsFloat64()}
+	case attribute.STRING:
+		av.Value = &commonpb.AnyValue_StringValue{StringValue: v.AsString()}
+	case attribute.BOOLSLICE:
+		values := make([]*commonpb.AnyValue, 0, len(v.AsBoolSlice()))
+		for _, b := range v.AsBoolSlice() {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: b}})
+		}
+		av.Value = &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}
+	case attribute.INT64SLICE:
+		values := make([]*commonpb.AnyValue, 0, len(v.AsInt64Slice()))
+		for _, i := range v.AsInt64Slice() {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: i}})
+		}
+		av.Value = &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}
+	case attribute.FLOAT64SLICE:
+		values := make([]*commonpb.AnyValue, 0, len(v.AsFloat64Slice()))
+		for _, f := range v.AsFloat64Slice() {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: f}})
+		}
+		av.Value = &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}
+	case attribute.STRINGSLICE:
+		values := make([]*commonpb.AnyValue, 0, len(v.AsStringSlice()))
+		for _, s := range v.AsStringSlice() {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}})
+		}
+		av.Value = &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}
+	default:
+		av.Value = &commonpb.AnyValue_StringValue{StringValue: "INVALID"}
+	}
+
+	return av
+}
+
+func resourceMetricsToProto(rm *metricdata.ResourceMetrics) *metricpb.ResourceMetrics {
+	sms := make([]*metricpb.ScopeMetrics, 0, len(rm.ScopeMetrics))
+	for _, sm := range rm.ScopeMetrics {
+		ms := make([]*metricpb.Metric, 0, len(sm.Metrics))
+		for _, m := range sm.Metrics {
+			if pm := metricToProto(m); pm != nil {
+				ms = append(ms, pm)
+			}
+		}
+		sms = append(sms, &metricpb.ScopeMetrics{
+			Scope:     scopeToProto(sm.Scope),
+			Metrics:   ms,
+			SchemaUrl: sm.Scope.SchemaURL,
+		})
+	}
+
+	out := &metricpb.ResourceMetrics{
+		Resource:     resourceToProto(rm.Resource),
+		ScopeMetrics: sms,
+	}
+	if rm.Resource != nil {
+		out.SchemaUrl = rm.Resource.SchemaURL()
+	}
+	return out
+}
+
+func metricToProto(m metricdata.Metrics) *metricpb.Metric {
+	out := &metricpb.Metric{
+		Name:        m.Name,
+		Description: m.Description,
+		Unit:        m.Unit,
+	}
+
+	switch data := m.Data.(type) {
+	case metricdata.Gauge[int64]:
+		out.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numberDataPointsToProto(data.DataPoints)}}
+	case metricdata.Gauge[float64]:
+		out.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numberDataPointsToProto(data.DataPoints)}}
+	case metricdata.Sum[int64]:
+		out.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints:             numberDataPointsToProto(data.DataPoints),
+			AggregationTemporality: temporalityToProto(data.Temporality),
+			IsMonotonic:            data.IsMonotonic,
+		}}
+	case metricdata.Sum[float64]:
+		out.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints:             numberDataPointsToProto(data.DataPoints),
+			AggregationTemporality: temporalityToProto(data.Temporality),
+			IsMonotonic:            data.IsMonotonic,
+		}}
+	case metricdata.Histogram:
+		out.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+			DataPoints:             histogramDataPointsToProto(data.DataPoints),
+			AggregationTemporality: temporalityToProto(data.Temporality),
+		}}
+	default:
+		return nil
+	}
+
+	return out
+}
+
+func numberDataPointsToProto[N int64 | float64](dps []metricdata.DataPoint[N]) []*metricpb.NumberDataPoint {
+	out := make([]*metricpb.NumberDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		ndp := &metricpb.NumberDataPoint{
+			Attributes:        attributesToProto(dp.Attributes.ToSlice()),
+			StartTimeUnixNano: timeToUnixNano(dp.StartTime),
+			TimeUnixNano:      timeToUnixNano(dp.Time),
+		}
+		switch v := any(dp.Value).(type) {
+		case int64:
+			ndp.Value = &metricpb.NumberDataPoint_AsInt{AsInt: v}
+		case float64:
+			ndp.Value = &metricpb.NumberDataPoint_AsDouble{AsDouble: v}
+		}
+		out = append(out, ndp)
+	}
+	return out
+}
+
+func histogramDataPointsToProto(dps []metricdata.HistogramDataPoint) []*metricpb.HistogramDataPoint {
+	out := make([]*metricpb.HistogramDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		sum := dp.Sum
+		hdp := &metricpb.HistogramDataPoint{
+			Attributes:        attributesToProto(dp.Attributes.ToSlice()),
+			StartTimeUnixNano: timeToUnixNano(dp.StartTime),
+			TimeUnixNano:      timeToUnixNano(dp.Time),
+			Count:             dp.Count,
+			Sum:               &sum,
+			BucketCounts:      dp.BucketCounts,
+			ExplicitBounds:    dp.Bounds,
+		}
+		if v, ok := dp.Min.Value(); ok {
+			hdp.Min = &v
+		}
+		if v, ok := dp.Max.Value(); ok {
+			hdp.Max = &v
+		}
+		out = append(out, hdp)
+	}
+	return out
+}
+
+func temporalityToProto(t metricdata.Temporality) metricpb.AggregationTemporality {
+	switch t {
+	case metricdata.DeltaTemporality:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	case metricdata.CumulativeTemporality:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	default:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED
+	}
+}
+
+func timeToUnixNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
+func resourceMetricsFromProto(rm *metricpb.ResourceMetrics) metricdata.ResourceMetrics {
+	out := metricdata.ResourceMetrics{
+		Resource:     resource.NewWithAttributes(rm.GetSchemaUrl(), attributesFromProto(rm.GetResource().GetAttributes())...),
+		ScopeMetrics: make([]metricdata.ScopeMetrics, 0, len(rm.GetScopeMetrics())),
+	}
+
+	for _, sm := range rm.GetScopeMetrics() {
+		ms := make([]metricdata.Metrics, 0, len(sm.GetMetrics()))
+		for _, m := range sm.GetMetrics() {
+			if data := metricDataFromProto(m); data != nil {
+				ms = append(ms, metricdata.Metrics{
+					Name:        m.GetName(),
+					Description: m.GetDescription(),
+					Unit:        m.GetUnit(),
+					Data:        data,
+				})
+			}
+		}
+		out.ScopeMetrics = append(out.ScopeMetrics, metricdata.ScopeMetrics{
+			Scope: instrumentation.Scope{
+				Name:      sm.GetScope().GetName(),
+				Version:   sm.GetScope().GetVersion(),
+				SchemaURL: sm.GetSchemaUrl(),
+			},
+			Metrics: ms,
+		})
+	}
+
+	return out
+}
+
+// metricDataFromProto returns the aggregation of m, the number type of the
+// gauges and the sums is the one of their first data point.
+func metricDataFromProto(m *metricpb.Metric) metricdata.Aggregation {
+	switch data := m.GetData().(type) {
+	case *metricpb.Metric_Gauge:
+		dps := data.Gauge.GetDataPoints()
+		if isIntDataPoints(dps) {
+			return metricdata.Gauge[int64]{DataPoints: numberDataPointsFromProto[int64](dps)}
+		}
+		return metricdata.Gauge[float64]{DataPoints: numberDataPointsFromProto[float64](dps)}
+	case *metricpb.Metric_Sum:
+		dps := data.Sum.GetDataPoints()
+		temporality := temporalityFromProto(data.Sum.GetAggregationTemporality())
+		if isIntDataPoints(dps) {
+			return metricdata.Sum[int64]{
+				DataPoints:  numberDataPointsFromProto[int64](dps),
+				Temporality: temporality,
+				IsMonotonic: data.Sum.GetIsMonotonic(),
+			}
+		}
+		return metricdata.Sum[float64]{
+			DataPoints:  numberDataPointsFromProto[float64](dps),
+			Temporality: temporality,
+			IsMonotonic: data.Sum.GetIsMonotonic(),
+		}
+	case *metricpb.Metric_Histogram:
+		return metricdata.Histogram{
+			DataPoints:  histogramDataPointsFromProto(data.Histogram.GetDataPoints()),
+			Temporality: temporalityFromProto(data.Histogram.GetAggregationTemporality()),
+		}
+	default:
+		return nil
+	}
+}
+
+func isIntDataPoints(dps []*metricpb.NumberDataPoint) bool {
+	if len(dps) == 0 {
+		return false
+	}
+	_, ok := dps[0].GetValue().(*metricpb.NumberDataPoint_AsInt)
+	return ok
+}
+
+func numberDataPointsFromProto[N int64 | float64](dps []*metricpb.NumberDataPoint) []metricdata.DataPoint[N] {
+	out := make([]metricdata.DataPoint[N], 0, len(dps))
+	for _, dp := range dps {
+		var value N
+		switch v := dp.GetValue().(type) {
+		case *metricpb.NumberDataPoint_AsInt:
+			value = N(v.AsInt)
+		case *metricpb.NumberDataPoint_AsDouble:
+			value = N(v.AsDouble)
+		}
+		out = append(out, metricdata.DataPoint[N]{
+			Attributes: attribute.NewSet(attributesFromProto(dp.GetAttributes())...),
+			StartTime:  unixNanoToTime(dp.GetStartTimeUnixNano()),
+			Time:       unixNanoToTime(dp.GetTimeUnixNano()),
+			Value:      value,
+		})
+	}
+	return out
+}
+
+func histogramDataPointsFromProto(dps []*metricpb.HistogramDataPoint) []metricdata.HistogramDataPoint {
+	out := make([]metricdata.HistogramDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		hdp := metricdata.HistogramDataPoint{
+			Attributes:   attribute.NewSet(attributesFromProto(dp.GetAttributes())...),
+			StartTime:    unixNanoToTime(dp.GetStartTimeUnixNano()),
+			Time:         unixNanoToTime(dp.GetTimeUnixNano()),
+			Count:        dp.GetCount(),
+			Sum:          dp.GetSum(),
+			BucketCounts: dp.GetBucketCounts(),
+			Bounds:       dp.GetExplicitBounds(),
+		}
+		if dp.Min != nil {
+			hdp.Min = metricdata.NewExtrema(dp.GetMin())
+		}
+		if dp.Max != nil {
+			hdp.Max = metricdata.NewExtrema(dp.GetMax())
+		}
+		out = append(out, hdp)
+	}
+	return out
+}
+
+func attributesFromProto(attrs []*commonpb.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		out = append(out, attribute.KeyValue{
+			Key:   attribute.Key(kv.GetKey()),
+			Value: attributeValueFromProto(kv.GetValue()),
+		})
+	}
+	return out
+}
+
+// attributeValueFromProto returns the attribute of the values produced by
+// attributeValueToProto, the arrays take the type of their first value.
+func attributeValueFromProto(v *commonpb.AnyValue) attribute.Value {
+	switch value := v.GetValue().(type) {
+	case *commonpb.AnyValue_BoolValue:
+		return attribute.BoolValue(value.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return attribute.Int64Value(value.IntValue)
+	case *commonpb.AnyValue_DoubleValue:
+		return attribute.Float64Value(value.DoubleValue)
+	case *commonpb.AnyValue_StringValue:
+		return attribute.StringValue(value.StringValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := value.ArrayValue.GetValues()
+		if len(values) == 0 {
+			return attribute.StringSliceValue(nil)
+		}
+		switch values[0].GetValue().(type) {
+		case *commonpb.AnyValue_BoolValue:
+			bs := make([]bool, 0, len(values))
+			for _, av := range values {
+				bs = append(bs, av.GetBoolValue())
+			}
+			return attribute.BoolSliceValue(bs)
+		case *commonpb.AnyValue_IntValue:
+			is := make([]int64, 0, len(values))
+			for _, av := range values {
+				is = append(is, av.GetIntValue())
+			}
+			return attribute.Int64SliceValue(is)
+		case *commonpb.AnyValue_DoubleValue:
+			fs := make([]float64, 0, len(values))
+			for _, av := range values {
+				fs = append(fs, av.GetDoubleValue())
+			}
+			return attribute.Float64SliceValue(fs)
+		default:
+			ss := make([]string, 0, len(values))
+			for _, av := range values {
+				ss = append(ss, av.GetStringValue())
+			}
+			return attribute.StringSliceValue(ss)
+		}
+	default:
+		return attribute.StringValue("INVALID")
+	}
+}
+
+func temporalityFromProto(t metricpb.AggregationTemporality) metricdata.Temporality {
+	switch t {
+	case metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA:
+		return metricdata.DeltaTemporality
+	default:
+		return metricdata.CumulativeTemporality
+	}
+}
+
+func unixNanoToTime(ns uint64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(ns))
+}
@@ -0,0 +1,103 @@
+package otelpp
+
+import (
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestResourceMetricsFromProto_RoundTrip(t *testing.T) {
+	start := time.Unix(0, 1_700_000_000_000_000_000)
+	end := start.Add(time.Minute)
+	attrs := attribute.NewSet(
+		attribute.String("route", "/users"),
+		attribute.Int64("status", 200),
+		attribute.Bool("cached", true),
+		attribute.Float64("ratio", 0.5),
+		attribute.StringSlice("tags", []string{"a", "b"}),
+	)
+
+	tests := []struct {
+		name string
+		data metricdata.Aggregation
+	}{
+		{
+			name: "int64 gauge",
+			data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{
+				{Attributes: attrs, StartTime: start, Time: end, Value: 42},
+			}},
+		},
+		{
+			name: "float64 gauge",
+			data: metricdata.Gauge[float64]{DataPoints: []metricdata.DataPoint[float64]{
+				{Attributes: attrs, StartTime: start, Time: end, Value: 4.2},
+			}},
+		},
+		{
+			name: "int64 sum",
+			data: metricdata.Sum[int64]{
+				Temporality: metricdata.CumulativeTemporality,
+				IsMonotonic: true,
+				DataPoints: []metricdata.DataPoint[int64]{
+					{Attributes: attrs, StartTime: start, Time: end, Value: 7},
+				},
+			},
+		},
+		{
+			name: "float64 sum",
+			data: metricdata.Sum[float64]{
+				Temporality: metricdata.DeltaTemporality,
+				IsMonotonic: false,
+				DataPoints: []metricdata.DataPoint[float64]{
+					{Attributes: attrs, StartTime: start, Time: end, Value: -1.5},
+				},
+			},
+		},
+		{
+			name: "histogram",
+			data: metricdata.Histogram{
+				Temporality: metricdata.DeltaTemporality,
+				DataPoints: []metricdata.HistogramDataPoint{
+					{
+						Attributes:   attrs,
+						StartTime:    start,
+						Time:         end,
+						Count:        3,
+						Sum:          1600,
+						Bounds:       []float64{500, 1000},
+						BucketCounts: []uint64{1, 1, 1},
+						Min:          metricdata.NewExtrema(100),
+						Max:          metricdata.NewExtrema(1000),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := metricdata.ResourceMetrics{
+				Resource: resource.NewWithAttributes("https://opentelemetry.io/schemas/1.17.0",
+					attribute.String("service.name", "api")),
+				ScopeMetrics: []metricdata.ScopeMetrics{{
+					Scope: instrumentation.Scope{Name: "otlp-stack", Version: "1.0.0"},
+					Metrics: []metricdata.Metrics{{
+						Name:        "requests",
+						Description: "requests served",
+						Unit:        "{request}",
+						Data:        tt.data,
+					}},
+				}},
+			}
+
+			got := resourceMetricsFromProto(resourceMetricsToProto(&want))
+
+			metricdatatest.AssertEqual(t, want, got)
+		})
+	}
+}
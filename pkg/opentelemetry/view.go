@@ -0,0 +1,209 @@
+package otelpp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+)
+
+/*
+ViewRule changes the metric stream of the instruments matching all of its
+non-empty fields, at least one of Name, Kind and Scope must be set:
+
+  - Name is a glob of the instrument name, where * matches any sequence of
+    characters and ? a single character, e.g. "http.server.*".
+  - Kind is one of counter, up_down_counter, histogram, observable_counter,
+    observable_up_down_counter or observable_gauge.
+  - Scope is a glob of the instrumentation scope name, the meter name.
+
+A matching instrument is dropped when Drop is set, otherwise its stream is
+changed by the non-empty fields:
+
+  - Rename is the name of the stream, it requires a Name without wildcard.
+  - Description is the description of the stream.
+  - Buckets are the explicit bucket boundaries, only used for histograms.
+  - AttributeKeys are the only attributes kept, e.g. to remove a high
+    cardinality attribute.
+*/
+type ViewRule struct {
+	Name          string    `mapstructure:"name"`
+	Kind          string    `mapstructure:"kind"`
+	Scope         string    `mapstructure:"scope"`
+	Rename        string    `mapstructure:"rename"`
+	Description   string    `mapstructure:"description"`
+	Buckets       []float64 `mapstructure:"buckets"`
+	AttributeKeys []string  `mapstructure:"attribute_keys"`
+	Drop          bool      `mapstructure:"drop"`
+}
+
+type compiledViewRule struct {
+	name        *regexp.Regexp
+	kind        sdkmetric.InstrumentKind
+	scope       *regexp.Regexp
+	rename      string
+	description string
+	buckets     aggregation.Aggregation
+	filter      attribute.Filter
+	drop        bool
+}
+
+/*
+NewRuleView creates a view from rules, in order, where the first rule
+matching an instrument changes the stream of the first view of defaults
+matching it, or the default stream of the instrument. The instruments not
+matched by any rule use the first matching view of defaults, so the rules
+compose with the default views, e.g. NewMetricHistogramBucketView, without
+exporting an instrument twice.
+*/
+func NewRuleView(rules []ViewRule, defaults []sdkmetric.View) (sdkmetric.View, error) {
+	compiled := make([]compiledViewRule, 0, len(rules))
+
+	for i, r := range rules {
+		c, err := compileViewRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid view rule %d: %w", i, err)
+		}
+		compiled = append(compiled, c)
+	}
+
+	return func(i sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		s, matched := defaultStream(i, defaults)
+
+		for _, r := range compiled {
+			if r.matches(i) {
+				return r.apply(i, s), true
+			}
+		}
+
+		return s, matched
+	}, nil
+}
+
+// defaultStream returns the stream of the first view matching i, or the
+// default stream of i.
+func defaultStream(i sdkmetric.Instrument, views []sdkmetric.View) (sdkmetric.Stream, bool) {
+	for _, v := range views {
+		if s, ok := v(i); ok {
+			return s, true
+		}
+	}
+
+	return sdkmetric.Stream{
+		Name:        i.Name,
+		Description: i.Description,
+		Unit:        i.Unit,
+	}, false
+}
+
+func compileViewRule(r ViewRule) (compiledViewRule, error) {
+	c := compiledViewRule{
+		rename:      r.Rename,
+		description: r.Description,
+		drop:        r.Drop,
+	}
+
+	if r.Name == "" && r.Kind == "" && r.Scope == "" {
+		return compiledViewRule{}, fmt.Errorf("missing name, kind or scope, the rule would match every instrument")
+	}
+
+	if r.Name != "" {
+		c.name = globToRegexp(r.Name)
+	}
+
+	if r.Rename != "" && (r.Name == "" || strings.ContainsAny(r.Name, "*?")) {
+		return compiledViewRule{}, fmt.Errorf("rename %q requires a name without wildcard", r.Rename)
+	}
+
+	if r.Kind != "" {
+		kind, err := instrumentKindFromString(r.Kind)
+		if err != nil {
+			return compiledViewRule{}, err
+		}
+		c.kind = kind
+	}
+
+	if r.Scope != "" {
+		c.scope = globToRegexp(r.Scope)
+	}
+
+	if len(r.Buckets) > 0 {
+		buckets := aggregation.ExplicitBucketHistogram{Boundaries: r.Buckets}
+		if err := buckets.Err(); err != nil {
+			return compiledViewRule{}, err
+		}
+		c.buckets = buckets
+	}
+
+	if len(r.AttributeKeys) > 0 {
+		keys := make(map[attribute.Key]bool, len(r.AttributeKeys))
+		for _, k := range r.AttributeKeys {
+			keys[attribute.Key(k)] = true
+		}
+		c.filter = func(kv attribute.KeyValue) bool {
+			return keys[kv.Key]
+		}
+	}
+
+	return c, nil
+}
+
+func (r compiledViewRule) matches(i sdkmetric.Instrument) bool {
+	if r.name != nil && !r.name.MatchString(i.Name) {
+		return false
+	}
+
+	if r.kind != 0 && r.kind != i.Kind {
+		return false
+	}
+
+	return r.scope == nil || r.scope.MatchString(i.Scope.Name)
+}
+
+// apply returns s changed by the rule.
+func (r compiledViewRule) apply(i sdkmetric.Instrument, s sdkmetric.Stream) sdkmetric.Stream {
+	if r.drop {
+		s.Aggregation = aggregation.Drop{}
+		return s
+	}
+
+	if r.rename != "" {
+		s.Name = r.rename
+	}
+
+	if r.description != "" {
+		s.Description = r.description
+	}
+
+	if r.buckets != nil && i.Kind == sdkmetric.InstrumentKindHistogram {
+		s.Aggregation = r.buckets.Copy()
+	}
+
+	if r.filter != nil {
+		s.AttributeFilter = r.filter
+	}
+
+	return s
+}
+
+func instrumentKindFromString(kind string) (sdkmetric.InstrumentKind, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "counter":
+		return sdkmetric.InstrumentKindCounter, nil
+	case "up_down_counter":
+		return sdkmetric.InstrumentKindUpDownCounter, nil
+	case "histogram":
+		return sdkmetric.InstrumentKindHistogram, nil
+	case "observable_counter":
+		return sdkmetric.InstrumentKindObservableCounter, nil
+	case "observable_up_down_counter":
+		return sdkmetric.InstrumentKindObservableUpDownCounter, nil
+	case "observable_gauge":
+		return sdkmetric.InstrumentKindObservableGauge, nil
+	default:
+		return 0, fmt.Errorf("invalid instrument kind: %q", kind)
+	}
+}